@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+type options struct {
+	refPath  *string
+	dccPath  *string
+	outPath  *string
+	maxShift *int
+	asJSON   *bool
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(&o); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run(o *options) error {
+	reference, err := dcc.FromFile(*o.refPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *o.refPath, err)
+	}
+
+	d, err := dcc.FromFile(*o.dccPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *o.dccPath, err)
+	}
+
+	alignments, err := d.EstimateAlignment(reference, *o.maxShift)
+	if err != nil {
+		return err
+	}
+
+	if *o.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(alignments); err != nil {
+			return err
+		}
+	} else {
+		for _, a := range alignments {
+			const percent = 100
+
+			fmt.Printf("direction %d: shift %v, %.1f%% of %d opaque pixels overlap\n",
+				a.Direction, a.Shift, a.Match()*percent, a.Opaque)
+		}
+	}
+
+	if *o.outPath == "" {
+		return nil
+	}
+
+	if err := d.ApplyAlignment(alignments); err != nil {
+		return err
+	}
+
+	data, err := d.Encode()
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(*o.outPath, data, 0o644); err != nil { //nolint:gomnd // file permissions
+		return fmt.Errorf("could not write file, %w", err)
+	}
+
+	return nil
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.refPath = flag.String("ref", "", "reference dcc file, or archive.mpq:path/in/archive (required)")
+	o.dccPath = flag.String("dcc", "", "dcc file to align, or archive.mpq:path/in/archive (required)")
+	o.outPath = flag.String("out", "", "write the aligned dcc to this file (optional)")
+	o.maxShift = flag.Int("max", dcc.DefaultMaxAlignShift, "largest shift to try along each axis, in pixels")
+	o.asJSON = flag.Bool("json", false, "print the alignments as json")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s -ref torso.dcc -dcc armor.dcc [-out aligned.dcc]\r\n", os.Args[0])
+		fmt.Println("\r\nEstimates the offset correction of each direction by matching the opaque pixels")
+		fmt.Println("against the same direction of the reference, and applies it when -out is given.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return *o.refPath == "" || *o.dccPath == ""
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const allDirections = -1
+
+type options struct {
+	dccPath   *string
+	outPath   *string
+	shift     *string
+	anchor    *string
+	recenter  *string
+	direction *int
+}
+
+// target is either a whole dcc or a single direction
+type target interface {
+	ShiftOffsets(dx, dy int)
+	SetAnchor(p image.Point)
+	Recenter(mode dcc.AnchorMode) image.Point
+	OpaqueBounds() image.Rectangle
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(&o); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run(o *options) error {
+	d, err := dcc.FromFile(*o.dccPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *o.dccPath, err)
+	}
+
+	var t target = d
+
+	if *o.direction != allDirections {
+		if *o.direction < 0 || *o.direction >= len(d.Directions()) {
+			return fmt.Errorf("%w: %d", dcc.ErrDirectionOutOfRange, *o.direction)
+		}
+
+		t = d.Direction(*o.direction)
+	}
+
+	if *o.shift != "" {
+		p, err := parsePoint(*o.shift)
+		if err != nil {
+			return err
+		}
+
+		t.ShiftOffsets(p.X, p.Y)
+	}
+
+	if *o.anchor != "" {
+		p, err := parsePoint(*o.anchor)
+		if err != nil {
+			return err
+		}
+
+		t.SetAnchor(p)
+	}
+
+	if *o.recenter != "" {
+		mode, err := dcc.AnchorModeFromString(*o.recenter)
+		if err != nil {
+			return err
+		}
+
+		shift := t.Recenter(mode)
+		fmt.Printf("recentered on %s, shifted by %v\n", mode, shift)
+	}
+
+	fmt.Printf("opaque bounds %v\n", t.OpaqueBounds())
+
+	data, err := d.Encode()
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(*o.outPath, data, 0o644); err != nil { //nolint:gomnd // file permissions
+		return fmt.Errorf("could not write file, %w", err)
+	}
+
+	return nil
+}
+
+func parsePoint(s string) (image.Point, error) {
+	var p image.Point
+
+	if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+		return p, fmt.Errorf("expecting x,y, got %q", s)
+	}
+
+	return p, nil
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.dccPath = flag.String("dcc", "", "input dcc file, or archive.mpq:path/in/archive (required)")
+	o.outPath = flag.String("out", "", "output dcc file (required)")
+	o.shift = flag.String("shift", "", "move the frames by dx,dy (optional)")
+	o.anchor = flag.String("anchor", "", "move the frames so that the pixel at x,y lands on the origin (optional)")
+	o.recenter = flag.String("recenter", "", "move the origin to the opaque pixels: feet, center (optional)")
+	o.direction = flag.Int("dir", allDirections, "only change this direction, all directions by default")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s -dcc in.dcc -out out.dcc [options]\r\n", os.Args[0])
+		fmt.Println("\r\nThe operations are applied in the order shift, anchor, recenter.")
+		fmt.Println("With -recenter, all directions are shifted by the same amount unless -dir is given.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return *o.dccPath == "" || *o.outPath == ""
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+	"github.com/OpenDiablo2/dcc/pkg/mpq"
+)
+
+// archives keeps the MPQ archives open while their files are checked
+type archives struct {
+	mutex sync.Mutex
+	open  map[string]*mpq.Archive
+}
+
+func newArchives() *archives {
+	return &archives{open: make(map[string]*mpq.Archive)}
+}
+
+func (a *archives) get(archivePath string) (*mpq.Archive, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if archive, found := a.open[archivePath]; found {
+		return archive, nil
+	}
+
+	archive, err := mpq.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	a.open[archivePath] = archive
+
+	return archive, nil
+}
+
+func (a *archives) close() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for archivePath, archive := range a.open {
+		_ = archive.Close()
+
+		delete(a.open, archivePath)
+	}
+}
+
+// readFile reads a file from disk or from an archive
+func (a *archives) readFile(filePath string) ([]byte, error) {
+	archivePath, name, ok := dcc.SplitArchivePath(filePath)
+	if !ok {
+		return ioutil.ReadFile(filePath)
+	}
+
+	archive, err := a.get(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return archive.ReadFile(name)
+}
+
+// expand finds the files in an archive that the name refers to. The name can be
+// a file, a glob pattern or a directory, which is searched for .dcc files.
+// Patterns and directories are matched against the archive's listfile.
+func (a *archives) expand(archivePath, name string) ([]string, error) {
+	archive, err := a.get(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.Trim(strings.ReplaceAll(name, "\\", "/"), "/")
+	isPattern := strings.ContainsAny(name, "*?[")
+
+	if !isPattern && name != "" && archive.Contains(name) {
+		return []string{archivePath + ":" + name}, nil
+	}
+
+	listed, err := archive.Listfile()
+	if err != nil {
+		return nil, fmt.Errorf("could not search %s, %w", archivePath, err)
+	}
+
+	pattern := strings.ToLower(name)
+	prefix := pattern + "/"
+
+	if pattern == "" {
+		prefix = ""
+	}
+
+	matches := make([]string, 0)
+
+	for _, listedName := range listed {
+		listedName = strings.ReplaceAll(listedName, "\\", "/")
+		lower := strings.ToLower(listedName)
+
+		var matched bool
+
+		if isPattern {
+			if matched, err = path.Match(pattern, lower); err != nil {
+				return nil, fmt.Errorf("bad pattern %q, %w", name, err)
+			}
+		} else {
+			matched = strings.HasPrefix(lower, prefix) && path.Ext(lower) == ".dcc"
+		}
+
+		if matched && archive.Contains(listedName) {
+			matches = append(matches, archivePath+":"+listedName)
+		}
+	}
+
+	if len(matches) == 0 && isPattern {
+		return nil, fmt.Errorf("no files match %q in %s", name, archivePath)
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("could not read path, %s:%s, %w", archivePath, name, mpq.ErrNotFound)
+	}
+
+	return matches, nil
+}
@@ -1,40 +1,343 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-)
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-import (
 	dcc "github.com/OpenDiablo2/dcc/pkg"
 )
 
+const (
+	exitOK = iota
+	exitFailures
+	exitUsage
+)
+
+type options struct {
+	tracePath    *string
+	traceJSON    *bool
+	workers      *int
+	reportPath   *string
+	reportFormat *string
+	quiet        *bool
+}
+
+// result is the outcome of checking a single file
+type result struct {
+	Path     string        `json:"path"`
+	OK       bool          `json:"ok"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+}
+
 func main() {
-	if len(os.Args) < 2 {
+	os.Exit(run())
+}
+
+func run() int {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
 		printUsage()
-		return
+		return exitUsage
 	}
 
-	srcPath := os.Args[1]
+	return checkArgs(&o, flag.Args())
+}
+
+// checkArgs checks the files that the arguments refer to and returns the exit code
+func checkArgs(o *options, args []string) int {
+	sources := newArchives()
+	defer sources.close()
 
-	fileContents, err := ioutil.ReadFile(srcPath)
+	paths, err := expandPaths(sources, args)
 	if err != nil {
-		const fmtErr = "could not read file, %v"
-		fmt.Print(fmt.Errorf(fmtErr, err))
+		fmt.Println(err)
+		return exitUsage
+	}
+
+	if len(paths) == 0 {
+		fmt.Println("no dcc files found")
+		return exitUsage
+	}
+
+	if *o.tracePath != "" {
+		if len(paths) > 1 {
+			fmt.Println("tracing is only supported when checking a single file")
+			return exitUsage
+		}
 
-		return
+		return checkTraced(paths[0], *o.tracePath, *o.traceJSON)
+	}
+
+	results := checkAll(sources, paths, *o.workers)
+
+	failed := 0
+
+	for idx := range results {
+		if !results[idx].OK {
+			failed++
+
+			fmt.Printf("FAIL %s: %s\n", results[idx].Path, results[idx].Error)
+		} else if !*o.quiet {
+			fmt.Printf("ok   %s\n", results[idx].Path)
+		}
+	}
+
+	fmt.Printf("%d files checked, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+
+	if *o.reportPath != "" {
+		if err := writeReport(*o.reportPath, *o.reportFormat, results); err != nil {
+			fmt.Println(err)
+			return exitUsage
+		}
+	}
+
+	if failed > 0 {
+		return exitFailures
+	}
+
+	return exitOK
+}
+
+// expandPaths turns the arguments into a sorted list of files. Directories are
+// searched recursively for .dcc files, and arguments containing glob patterns
+// are expanded. Arguments like "archive.mpq:path" are looked up in the archive.
+func expandPaths(sources *archives, args []string) ([]string, error) {
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, arg := range args {
+		if archivePath, name, ok := dcc.SplitArchivePath(arg); ok {
+			inArchive, err := sources.expand(archivePath, name)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, match := range inArchive {
+				add(match)
+			}
+
+			continue
+		}
+
+		matches := []string{arg}
+
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("bad pattern %q, %w", arg, err)
+			}
+
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("could not read path, %w", err)
+			}
+
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			err = filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".dcc") {
+					add(path)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("could not read directory, %w", err)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// checkAll decodes every file on a pool of workers, the results are in
+// the same order as the paths
+func checkAll(sources *archives, paths []string, numWorkers int) []result {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	results := make([]result, len(paths))
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+
+	for worker := 0; worker < numWorkers; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				results[idx] = check(sources, paths[idx])
+			}
+		}()
+	}
+
+	for idx := range paths {
+		jobs <- idx
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func check(sources *archives, path string) result {
+	start := time.Now()
+	r := result{Path: path, OK: true}
+
+	if err := decodeFile(sources, path); err != nil {
+		r.OK = false
+		r.Error = err.Error()
+	}
+
+	r.Duration = time.Since(start)
+	r.Seconds = r.Duration.Seconds()
+
+	return r
+}
+
+// decodeFile reads and decodes the file, a panic while decoding is returned as
+// the error of the file so that the other files are still checked
+func decodeFile(sources *archives, path string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fileContents, err := sources.readFile(path)
+	if err != nil {
+		const fmtErr = "could not read file, %v"
+		return fmt.Errorf(fmtErr, err)
 	}
 
 	_, err = dcc.FromBytes(fileContents)
+
+	return err
+}
+
+// checkTraced decodes the file while writing a trace log to the given path,
+// the log is written to stdout if the path is "-"
+func checkTraced(path, tracePath string, asJSON bool) int {
+	fileContents, err := dcc.ReadFile(path)
 	if err != nil {
-		fmt.Print(err)
-		return
+		const fmtErr = "could not read file, %v"
+		fmt.Println(fmt.Errorf(fmtErr, err))
+
+		return exitUsage
+	}
+
+	if err := decodeTraced(fileContents, tracePath, asJSON); err != nil {
+		fmt.Println(err)
+
+		if errors.Is(err, errTraceOutput) {
+			return exitUsage
+		}
+
+		return exitFailures
 	}
 
 	fmt.Println("DCC decode successful")
+
+	return exitOK
+}
+
+var errTraceOutput = errors.New("could not write trace")
+
+func decodeTraced(data []byte, tracePath string, asJSON bool) error {
+	out := os.Stdout
+
+	if tracePath != "-" {
+		f, err := os.Create(tracePath)
+		if err != nil {
+			return fmt.Errorf("%w, %v", errTraceOutput, err)
+		}
+
+		defer func() { _ = f.Close() }()
+
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+
+	format := dcc.TraceFormatText
+	if asJSON {
+		format = dcc.TraceFormatJSON
+	}
+
+	tracer := dcc.NewLogTracer(w, format)
+
+	// the trace is written even when decoding fails, that is when it is needed most
+	_, decodeErr := dcc.FromBytesTraced(data, tracer)
+
+	if err := tracer.Err(); err != nil {
+		return fmt.Errorf("%w, %v", errTraceOutput, err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("%w, %v", errTraceOutput, err)
+	}
+
+	return decodeErr
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.tracePath = flag.String("trace", "", "write a decoder trace log to this path, - for stdout (single file only)")
+	o.traceJSON = flag.Bool("trace-json", false, "write the trace log as json lines")
+	o.workers = flag.Int("workers", runtime.NumCPU(), "number of files to check at the same time")
+	o.reportPath = flag.String("report", "", "write per-file results to this path (optional)")
+	o.reportFormat = flag.String("format", reportFormatJSON, "report format: json or junit")
+	o.quiet = flag.Bool("quiet", false, "only print failures and the summary")
+
+	flag.Parse()
+
+	if *o.reportFormat != reportFormatJSON && *o.reportFormat != reportFormatJUnit {
+		fmt.Printf("unknown report format %q\n", *o.reportFormat)
+		return true
+	}
+
+	return flag.NArg() < 1
 }
 
 func printUsage() {
-	fmt.Printf("Usage:\r\n\t%s path/to/file.dcc", os.Args[0])
+	fmt.Printf("Usage:\r\n\t%s [options] path/to/file.dcc|path/to/dir|'glob/*.dcc'|'archive.mpq:path/in/archive' ...\r\n", os.Args[0])
+	fmt.Println("\r\nExits with 1 when any file fails to decode, and 2 on usage or I/O errors.")
+	flag.PrintDefaults()
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+// writeTestFiles writes a valid dcc, a broken dcc and a file that is not a dcc
+// to a new directory
+func writeTestFiles(t *testing.T) string {
+	t.Helper()
+
+	d := dcc.New()
+	if err := d.AddDirection(&dcc.Direction{}); err != nil {
+		t.Fatal(err)
+	}
+
+	img := image.NewPaletted(image.Rect(-2, -4, 2, 0), nil)
+	img.Pix[0] = 1
+
+	if err := d.Direction(0).InsertFrame(0, dcc.NewFrame(img)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := d.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+
+	for name, contents := range map[string][]byte{
+		"good.dcc":       data,
+		"sub/broken.DCC": data[:len(data)/2],
+		"sub/notes.txt":  []byte("not a dcc"),
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, contents, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func testOptions(workers int) *options {
+	tracePath, traceJSON, reportPath, reportFormat, quiet := "", false, "", reportFormatJSON, true
+
+	return &options{
+		tracePath:    &tracePath,
+		traceJSON:    &traceJSON,
+		workers:      &workers,
+		reportPath:   &reportPath,
+		reportFormat: &reportFormat,
+		quiet:        &quiet,
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	root := writeTestFiles(t)
+	good, broken := filepath.Join(root, "good.dcc"), filepath.Join(root, "sub", "broken.DCC")
+
+	sources := newArchives()
+	defer sources.close()
+
+	for _, test := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{root}, []string{good, broken}},
+		{[]string{filepath.Join(root, "*.dcc"), good}, []string{good}},
+		{[]string{filepath.Join(root, "sub", "*")}, []string{broken, filepath.Join(root, "sub", "notes.txt")}},
+	} {
+		got, err := expandPaths(sources, test.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("%v, expected %v, got %v", test.args, test.want, got)
+		}
+	}
+
+	if _, err := expandPaths(sources, []string{filepath.Join(root, "*.png")}); err == nil {
+		t.Fatal("expected an error for a glob without matches")
+	}
+}
+
+func TestCheckArgsExitCodes(t *testing.T) {
+	root := writeTestFiles(t)
+
+	for _, test := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{filepath.Join(root, "good.dcc")}, exitOK},
+		{[]string{root}, exitFailures},
+		{[]string{filepath.Join(root, "*.png")}, exitUsage},
+		{[]string{filepath.Join(root, "missing.dcc")}, exitUsage},
+	} {
+		if got := checkArgs(testOptions(4), test.args); got != test.want {
+			t.Fatalf("%v, expected exit code %d, got %d", test.args, test.want, got)
+		}
+	}
+}
+
+func TestCheckAllKeepsOrder(t *testing.T) {
+	root := writeTestFiles(t)
+	good, broken := filepath.Join(root, "good.dcc"), filepath.Join(root, "sub", "broken.DCC")
+
+	paths := make([]string, 0)
+	for idx := 0; idx < 16; idx++ {
+		paths = append(paths, good, broken)
+	}
+
+	sources := newArchives()
+	defer sources.close()
+
+	for idx, r := range checkAll(sources, paths, 8) {
+		if r.Path != paths[idx] || r.OK != (paths[idx] == good) {
+			t.Fatalf("result %d, expected %s ok %v, got %s ok %v", idx, paths[idx], paths[idx] == good, r.Path, r.OK)
+		}
+	}
+}
+
+func testResults() []result {
+	return []result{
+		{Path: "good.dcc", OK: true, Seconds: 0.5},
+		{Path: "broken.dcc", Error: "error decoding dcc body", Seconds: 0.25},
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	if err := writeJUnitReport(buf, testResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitTestSuites
+
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Suites) != 1 {
+		t.Fatalf("expected a single test suite, got %d", len(report.Suites))
+	}
+
+	suite := report.Suites[0]
+
+	if suite.Name != "dcc-check" || suite.Tests != 2 || suite.Failures != 1 || suite.Time != 0.75 {
+		t.Fatalf("unexpected test suite %+v", suite)
+	}
+
+	if suite.Cases[0].Name != "good.dcc" || suite.Cases[0].Failure != nil {
+		t.Fatalf("expected good.dcc to pass, got %+v", suite.Cases[0])
+	}
+
+	failure := suite.Cases[1].Failure
+	if suite.Cases[1].Name != "broken.dcc" || failure == nil || failure.Text != "error decoding dcc body" {
+		t.Fatalf("expected broken.dcc to fail with its error, got %+v", suite.Cases[1])
+	}
+}
+
+func TestJSONReport(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	if err := writeJSONReport(buf, testResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	var report jsonReport
+
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 2 || report.Passed != 1 || report.Failed != 1 || report.Results[1].Error == "" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	reportFormatJSON  = "json"
+	reportFormatJUnit = "junit"
+)
+
+type jsonReport struct {
+	Total   int      `json:"total"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Results []result `json:"results"`
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     float64         `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeReport(path, format string, results []result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create report, %w", err)
+	}
+
+	switch format {
+	case reportFormatJSON:
+		err = writeJSONReport(f, results)
+	case reportFormatJUnit:
+		err = writeJUnitReport(f, results)
+	default:
+		err = fmt.Errorf("unknown report format %q", format)
+	}
+
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not write report, %w", err)
+	}
+
+	return f.Close()
+}
+
+func writeJSONReport(w io.Writer, results []result) error {
+	report := jsonReport{Total: len(results), Results: results}
+
+	for idx := range results {
+		if results[idx].OK {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+func writeJUnitReport(w io.Writer, results []result) error {
+	suite := junitTestSuite{
+		Name:  "dcc-check",
+		Tests: len(results),
+		Cases: make([]junitTestCase, len(results)),
+	}
+
+	for idx := range results {
+		r := &results[idx]
+
+		suite.Time += r.Seconds
+		suite.Cases[idx] = junitTestCase{
+			Name:      r.Path,
+			ClassName: "dcc",
+			Time:      r.Seconds,
+		}
+
+		if !r.OK {
+			suite.Failures++
+			suite.Cases[idx].Failure = &junitFailure{Message: "decode failed", Text: r.Error}
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
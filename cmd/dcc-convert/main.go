@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
-	"image/color"
+	"image"
+	"image/gif"
 	"image/png"
 	"io/ioutil"
 	"log"
@@ -12,13 +13,27 @@ import (
 	"path/filepath"
 
 	dcc "github.com/OpenDiablo2/dcc/pkg"
-	gpl "github.com/gravestench/gpl/pkg"
 )
 
+const defaultGifDelay = 4 // in 100ths of a second
+
 type options struct {
-	dccPath *string
-	palPath *string
-	pngPath *string
+	dccPath   *string
+	palPath   *string
+	pngPath   *string
+	gifPath   *string
+	sheetPath *string
+	drawMode  *string
+	gifDelay  *int
+	dccOut    *string
+	trim      *bool
+	mirror    *bool
+	frames    *int
+	curve     *string
+	animData  *string
+	cofName   *string
+	scaleMode *string
+	factor    *float64
 }
 
 func main() {
@@ -35,7 +50,13 @@ func main() {
 	//palBaseName := path.Base(*o.palPath)
 	//palFileName := fileNameWithoutExt(palBaseName)
 
-	data, err := ioutil.ReadFile(*o.dccPath)
+	mode, err := dcc.DrawModeFromString(*o.drawMode)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	data, err := dcc.ReadFile(*o.dccPath)
 	if err != nil {
 		const fmtErr = "could not read file, %v"
 		fmt.Print(fmt.Errorf(fmtErr, err))
@@ -49,34 +70,105 @@ func main() {
 		return
 	}
 
-	outfilePath := *o.pngPath
-	numDirections := len(d.Directions())
-	framesPerDirection := len(d.Direction(0).Frames())
-	hasMultipleImages := numDirections > 1 || framesPerDirection > 1
-
-	if hasMultipleImages {
-		noExt := fileNameWithoutExt(outfilePath)
-		outfilePath = noExt + "_d%v_f%v.png"
-	}
-
 	if *o.palPath != "" {
-		palData, err := ioutil.ReadFile(*o.palPath)
+		format, err := dcc.PaletteFormatFromPath(*o.palPath)
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
 
-		gplInstance, err := gpl.Decode(bytes.NewBuffer(palData))
+		palData, err := dcc.ReadFile(*o.palPath)
 		if err != nil {
-			fmt.Println("palette is not a GIMP palette file...")
+			fmt.Println(err)
+			return
+		}
+
+		p, err := dcc.DecodePalette(bytes.NewReader(palData), format)
+		if err != nil {
+			fmt.Println(err)
 			return
 		}
 
-		d.SetPalette(color.Palette(*gplInstance))
+		d.SetPalette(p)
 	} else {
 		d.SetPalette(nil)
 	}
 
+	if *o.mirror {
+		if _, err := d.MirrorMissingDirections(); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if *o.trim {
+		d.Trim()
+	}
+
+	if *o.frames > 0 {
+		curve, err := dcc.RetimeCurveFromString(*o.curve)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := d.Retime(*o.frames, curve); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if *o.scaleMode != "" {
+		scaleMode, err := dcc.ScaleModeFromString(*o.scaleMode)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		factor := *o.factor
+		if factor == 0 {
+			factor = float64(scaleMode.Factor())
+		}
+
+		if err := d.Scale(scaleMode, factor); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if *o.dccOut != "" {
+		writeDCC(d, *o.dccOut)
+	}
+
+	if *o.pngPath != "" {
+		writePNGs(d, *o.pngPath, mode)
+	}
+
+	if *o.gifPath != "" {
+		delays, err := gifDelays(*o.animData, *o.cofName, len(d.Direction(0).Frames()))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		writeGIFs(d, *o.gifPath, mode, *o.gifDelay, delays)
+	}
+
+	if *o.sheetPath != "" {
+		writePNG(*o.sheetPath, d.Sheet(mode))
+	}
+}
+
+func writePNGs(d *dcc.DCC, outfilePath string, mode dcc.DrawMode) {
+	numDirections := len(d.Directions())
+	framesPerDirection := len(d.Direction(0).Frames())
+	hasMultipleImages := numDirections > 1 || framesPerDirection > 1
+
+	if hasMultipleImages {
+		noExt := fileNameWithoutExt(outfilePath)
+		outfilePath = noExt + "_d%v_f%v.png"
+	}
+
 	for dirIdx := 0; dirIdx < numDirections; dirIdx++ {
 		frames := d.Direction(dirIdx).Frames()
 
@@ -86,31 +178,120 @@ func main() {
 				outPath = fmt.Sprintf(outfilePath, dirIdx, frameIdx)
 			}
 
-			f, err := os.Create(outPath)
-			if err != nil {
-				log.Fatal(err)
-			}
+			writePNG(outPath, frames[frameIdx].Image(mode))
+		}
+	}
+}
 
-			if err := png.Encode(f, frames[frameIdx]); err != nil {
-				_ = f.Close()
-				log.Fatal(err)
-			}
+// gifDelays returns the frame delays of the animation in AnimationData.d2, or
+// nil when no animation data is given
+func gifDelays(animDataPath, cofName string, frames int) ([]int, error) {
+	if animDataPath == "" {
+		return nil, nil
+	}
 
-			if err := f.Close(); err != nil {
-				log.Fatal(err)
-			}
+	data, err := dcc.ReadFile(animDataPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read animation data, %w", err)
+	}
+
+	animData, err := dcc.AnimationDataFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	record := animData.Record(cofName)
+	if record == nil {
+		return nil, fmt.Errorf("no animation data for %q", cofName)
+	}
+
+	return record.GIFDelays(frames), nil
+}
+
+func writeGIFs(d *dcc.DCC, outfilePath string, mode dcc.DrawMode, delay int, delays []int) {
+	numDirections := len(d.Directions())
+
+	if numDirections > 1 {
+		noExt := fileNameWithoutExt(outfilePath)
+		outfilePath = noExt + "_d%v.gif"
+	}
+
+	for dirIdx := 0; dirIdx < numDirections; dirIdx++ {
+		outPath := outfilePath
+		if numDirections > 1 {
+			outPath = fmt.Sprintf(outfilePath, dirIdx)
+		}
+
+		f, err := os.Create(outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		g := d.Direction(dirIdx).GIF(mode, delay)
+		if delays != nil {
+			g.Delay = delays
+		}
+
+		if err := gif.EncodeAll(f, g); err != nil {
+			_ = f.Close()
+			log.Fatal(err)
+		}
+
+		if err := f.Close(); err != nil {
+			log.Fatal(err)
 		}
 	}
 }
 
+func writeDCC(d *dcc.DCC, outPath string) {
+	data, err := d.Encode()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(outPath, data, 0o644); err != nil { //nolint:gomnd // file permissions
+		log.Fatal(err)
+	}
+}
+
+func writePNG(outPath string, img image.Image) {
+	f, err := os.Create(outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		log.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func parseOptions(o *options) (terminate bool) {
-	o.dccPath = flag.String("dcc", "", "input dcc file (required)")
-	o.palPath = flag.String("pal", "", "input pal file (optional)")
+	o.dccPath = flag.String("dcc", "", "input dcc file, or archive.mpq:path/in/archive (required)")
+	o.palPath = flag.String("pal", "", "input pal file, or archive.mpq:path/in/archive (optional)")
 	o.pngPath = flag.String("png", "", "path to png file (optional)")
+	o.gifPath = flag.String("gif", "", "path to gif file (optional)")
+	o.sheetPath = flag.String("sheet", "", "path to sprite sheet png file (optional)")
+	o.drawMode = flag.String("mode", dcc.DrawModeNormal.String(),
+		"draw mode: normal, trans25, trans50, trans75, additive, luminance")
+	o.gifDelay = flag.Int("delay", defaultGifDelay, "gif frame delay in 100ths of a second")
+	o.animData = flag.String("animdata", "", "AnimationData.d2 file, or archive.mpq:path/in/archive, for the gif frame delays (optional)")
+	o.cofName = flag.String("cof", "", "name of the animation in the animation data, like AMA1HTH")
+	o.dccOut = flag.String("encode", "", "path to re-encoded dcc file (optional)")
+	o.mirror = flag.Bool("mirror", false, "fill empty directions by flipping the directions that mirror them")
+	o.frames = flag.Int("frames", 0, "retime every direction to this many frames (optional)")
+	o.curve = flag.String("curve", dcc.RetimeLinear.String(), "retime curve: linear, ease-in, ease-out, ease-in-out")
+	o.trim = flag.Bool("trim", false, "shrink the frames to their opaque pixels before writing")
+	o.scaleMode = flag.String("scale", "", "scale the frames before writing: nearest, scale2x, scale3x, epx, hq2x (optional)")
+	o.factor = flag.Float64("factor", 0, "scale factor, required for nearest, the other scale modes have a fixed factor")
 
 	flag.Parse()
 
-	if *o.dccPath == "" {
+	if *o.dccPath == "" || (*o.animData != "" && *o.cofName == "") {
 		return true
 	}
 
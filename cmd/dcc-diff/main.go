@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const (
+	exitSame = iota
+	exitDifferent
+	exitError
+)
+
+type options struct {
+	asJSON    *bool
+	imagesDir *string
+	palPath   *string
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		return exitError
+	}
+
+	a, err := load(flag.Arg(0), *o.palPath)
+	if err != nil {
+		fmt.Println(err)
+		return exitError
+	}
+
+	b, err := load(flag.Arg(1), *o.palPath)
+	if err != nil {
+		fmt.Println(err)
+		return exitError
+	}
+
+	diff := dcc.Compare(a, b)
+
+	if *o.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(diff); err != nil {
+			fmt.Println(err)
+			return exitError
+		}
+	} else {
+		printDiff(os.Stdout, diff)
+	}
+
+	if *o.imagesDir != "" {
+		if err := writeImages(*o.imagesDir, a, b, diff); err != nil {
+			fmt.Println(err)
+			return exitError
+		}
+	}
+
+	if diff.Equal() {
+		return exitSame
+	}
+
+	return exitDifferent
+}
+
+func load(path, palPath string) (*dcc.DCC, error) {
+	d, err := dcc.FromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if palPath == "" {
+		return d, nil
+	}
+
+	format, err := dcc.PaletteFormatFromPath(palPath)
+	if err != nil {
+		return nil, err
+	}
+
+	palData, err := dcc.ReadFile(palPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read palette file, %w", err)
+	}
+
+	p, err := dcc.DecodePalette(bytes.NewReader(palData), format)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetPalette(p)
+
+	return d, nil
+}
+
+func printDiff(w io.Writer, diff *dcc.Diff) {
+	if diff.Equal() {
+		fmt.Fprintln(w, "no differences")
+		return
+	}
+
+	for _, field := range diff.Header {
+		fmt.Fprintf(w, "header: %s\n", field)
+	}
+
+	for _, dir := range diff.Directions {
+		for _, field := range dir.Fields {
+			fmt.Fprintf(w, "direction %d: %s\n", dir.Direction, field)
+		}
+
+		for _, frame := range dir.Frames {
+			for _, field := range frame.Fields {
+				fmt.Fprintf(w, "direction %d frame %d: %s\n", dir.Direction, frame.Frame, field)
+			}
+
+			if frame.Pixels.Changed == 0 {
+				continue
+			}
+
+			fmt.Fprintf(w, "direction %d frame %d: %d of %d pixels differ, within %s\n",
+				dir.Direction, frame.Frame, frame.Pixels.Changed, frame.Pixels.Compared, frame.Pixels.Bounds)
+		}
+	}
+}
+
+// writeImages writes a highlighted diff image for every frame with changed pixels
+func writeImages(dir string, a, b *dcc.DCC, diff *dcc.Diff) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gomnd // file permissions
+		return fmt.Errorf("could not create image directory, %w", err)
+	}
+
+	for _, dirDiff := range diff.Directions {
+		for _, frameDiff := range dirDiff.Frames {
+			if frameDiff.Pixels.Changed == 0 {
+				continue
+			}
+
+			frameA := a.Direction(dirDiff.Direction).Frame(frameDiff.Frame)
+			frameB := b.Direction(dirDiff.Direction).Frame(frameDiff.Frame)
+
+			name := fmt.Sprintf("diff_d%v_f%v.png", dirDiff.Direction, frameDiff.Frame)
+
+			f, err := os.Create(filepath.Join(dir, name))
+			if err != nil {
+				return fmt.Errorf("could not create image, %w", err)
+			}
+
+			if err := png.Encode(f, dcc.DiffImage(frameA, frameB)); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("could not write image, %w", err)
+			}
+
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.asJSON = flag.Bool("json", false, "print the differences as json")
+	o.imagesDir = flag.String("images", "", "write highlighted diff images of the changed frames to this directory (optional)")
+	o.palPath = flag.String("pal", "", "palette used for the diff images (optional)")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s [options] a.dcc b.dcc\r\n", os.Args[0])
+		fmt.Println("\r\nExits with 1 when the files differ, and 2 on errors.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return flag.NArg() != 2 //nolint:gomnd // two files
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+type options struct {
+	asJSON *bool
+	stats  *bool
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		return
+	}
+
+	srcPath := flag.Arg(0)
+
+	fileContents, err := dcc.ReadFile(srcPath)
+	if err != nil {
+		const fmtErr = "could not read file, %v"
+		fmt.Println(fmt.Errorf(fmtErr, err))
+		os.Exit(1)
+	}
+
+	d, err := dcc.FromBytes(fileContents)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	info := d.Info()
+
+	if *o.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(info); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if *o.stats {
+		printStats(os.Stdout, info)
+		return
+	}
+
+	infoTree(srcPath, info).print(os.Stdout, "", "")
+}
+
+// printStats prints a table of the compression statistics of each direction
+func printStats(w io.Writer, info *dcc.Info) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "dir\tcells\tequal\tmask0\tmask1\tmask2\tmask3\tmask4\traw\tdisp\t"+
+		"solid\t1bit\t2bit\tEC%\tPM%\tET%\tRP%\tPCD%\tcolors\traw size\tcoded size\tratio\t")
+
+	for dirIdx := range info.Directions {
+		s := info.Directions[dirIdx].Stats
+		share := s.StreamBits.Share()
+
+		fmt.Fprintf(tw, "%d\t%d\t%d\t", dirIdx, s.Cells, s.EqualCells)
+
+		for _, count := range s.PixelMaskPopCount {
+			fmt.Fprintf(tw, "%d\t", count)
+		}
+
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t", s.RawCodedCells, s.DisplacementCodedCells,
+			s.SolidCells, s.OneBitCells, s.TwoBitCells)
+
+		for _, fraction := range share {
+			fmt.Fprintf(tw, "%.1f\t", fraction*100) // nolint:gomnd // percent
+		}
+
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.2f\t\n", s.PaletteEntries, s.RawSize, s.CodedSize(), s.Ratio())
+	}
+
+	_ = tw.Flush()
+}
+
+// node is a line of text in the printed tree, with its child lines
+type node struct {
+	text     string
+	children []*node
+}
+
+func (n *node) add(format string, args ...interface{}) *node {
+	child := &node{text: fmt.Sprintf(format, args...)}
+	n.children = append(n.children, child)
+
+	return child
+}
+
+func (n *node) print(w io.Writer, prefix, childPrefix string) {
+	fmt.Fprintf(w, "%s%s\n", prefix, n.text)
+
+	for idx, child := range n.children {
+		if idx == len(n.children)-1 {
+			child.print(w, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.print(w, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+func infoTree(name string, info *dcc.Info) *node {
+	root := &node{text: name}
+
+	root.add("Version: %d", info.Version)
+	root.add("TotalSizeCoded: %d", info.TotalSizeCoded)
+	root.add("Directions: %d", info.NumberOfDirections)
+	root.add("FramesPerDirection: %d", info.FramesPerDirection)
+	root.add("DirectionOffsets: %v", info.DirectionOffsets)
+
+	for dirIdx := range info.Directions {
+		dir := &info.Directions[dirIdx]
+		dirNode := root.add("Direction %d", dirIdx)
+
+		dirNode.add("OutSizeCoded: %d", dir.OutSizeCoded)
+		dirNode.add("CompressionFlags: %d", dir.CompressionFlags)
+
+		bits := dirNode.add("BitWidths")
+		bits.add("Variable0: %d", dir.BitWidths.Variable0)
+		bits.add("Width: %d", dir.BitWidths.Width)
+		bits.add("Height: %d", dir.BitWidths.Height)
+		bits.add("XOffset: %d", dir.BitWidths.XOffset)
+		bits.add("YOffset: %d", dir.BitWidths.YOffset)
+		bits.add("OptionalData: %d", dir.BitWidths.OptionalData)
+		bits.add("CodedBytes: %d", dir.BitWidths.CodedBytes)
+
+		streams := dirNode.add("SubstreamSizes")
+		streams.add("EqualCells: %d", dir.Substreams.EqualCells)
+		streams.add("PixelMask: %d", dir.Substreams.PixelMask)
+		streams.add("EncodingType: %d", dir.Substreams.EncodingType)
+		streams.add("RawPixelCodes: %d", dir.Substreams.RawPixelCodes)
+
+		dirNode.add("Box: %s", dir.Box)
+		dirNode.add("PaletteEntries (%d): %s", len(dir.PaletteEntries), joinInts(dir.PaletteEntries))
+
+		for frameIdx := range dir.Frames {
+			frame := &dir.Frames[frameIdx]
+			frameNode := dirNode.add("Frame %d", frameIdx)
+
+			frameNode.add("Width: %d", frame.Width)
+			frameNode.add("Height: %d", frame.Height)
+			frameNode.add("XOffset: %d", frame.XOffset)
+			frameNode.add("YOffset: %d", frame.YOffset)
+			frameNode.add("NumberOfCodedBytes: %d", frame.NumberOfCodedBytes)
+			frameNode.add("FrameIsBottomUp: %v", frame.FrameIsBottomUp)
+		}
+	}
+
+	return root
+}
+
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+
+	for idx := range values {
+		s[idx] = fmt.Sprint(values[idx])
+	}
+
+	return strings.Join(s, " ")
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.asJSON = flag.Bool("json", false, "print the information as json")
+	o.stats = flag.Bool("stats", false, "print a table of compression statistics for each direction")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s [-json | -stats] path/to/file.dcc|archive.mpq:path/in/archive\r\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return flag.NArg() < 1
+}
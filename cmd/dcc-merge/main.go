@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+type options struct {
+	outPath *string
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(*o.outPath, flag.Args()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run(outPath string, paths []string) error {
+	dccs := make([]*dcc.DCC, len(paths))
+
+	for idx, path := range paths {
+		d, err := dcc.FromFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		dccs[idx] = d
+	}
+
+	merged, err := dcc.Concat(dccs...)
+	if err != nil {
+		return err
+	}
+
+	data, err := merged.Encode()
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(outPath, data, 0o644); err != nil { //nolint:gomnd // file permissions
+		return fmt.Errorf("could not write file, %w", err)
+	}
+
+	return nil
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.outPath = flag.String("out", "", "output dcc file (required)")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s -out out.dcc first.dcc second.dcc [...]\r\n", os.Args[0])
+		fmt.Println("\r\nAppends the frames of each file to those of the file before it, in every direction.")
+		fmt.Println("The files must have the same number of directions, and can be archive.mpq:path/in/archive.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return *o.outPath == "" || flag.NArg() < 2 //nolint:gomnd // at least two files
+}
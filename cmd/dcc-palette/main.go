@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+type options struct {
+	dccPath   *string
+	palPath   *string
+	palFormat *string
+	outFormat *string
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		return
+	}
+
+	p := *dcc.DefaultPalette()
+
+	if *o.palPath != "" {
+		loaded, err := loadPalette(*o.palPath, *o.palFormat)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		p = loaded
+	}
+
+	// the palette attached to a dcc always has 256 colors, see DCC.SetPalette
+	if *o.dccPath != "" {
+		d, err := dcc.FromFile(*o.dccPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		d.SetPalette(p)
+		p = *d.Palette()
+	}
+
+	for _, outPath := range flag.Args() {
+		if err := savePalette(outPath, *o.outFormat, p); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+func loadPalette(path, formatName string) (color.Palette, error) {
+	format, err := paletteFormat(path, formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := dcc.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read palette file, %w", err)
+	}
+
+	return dcc.DecodePalette(bytes.NewReader(data), format)
+}
+
+func savePalette(path, formatName string, p color.Palette) error {
+	format, err := paletteFormat(path, formatName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create palette file, %w", err)
+	}
+
+	if err := dcc.EncodePalette(f, p, format); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// paletteFormat uses the format name when given, otherwise the file extension
+func paletteFormat(path, formatName string) (dcc.PaletteFormat, error) {
+	if formatName != "" {
+		return dcc.PaletteFormatFromString(formatName)
+	}
+
+	return dcc.PaletteFormatFromPath(path)
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.dccPath = flag.String("dcc", "", "write the palette of this dcc file, as set with -pal (optional)")
+	o.palPath = flag.String("pal", "", "input palette file (optional, defaults to greyscale)")
+	o.palFormat = flag.String("in", "", "input palette format: gpl, act, jasc, dat, png (optional)")
+	o.outFormat = flag.String("out", "", "output palette format: gpl, act, jasc, dat, png (optional)")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s [-dcc path/to/file.dcc] [-pal path/to/palette] path/to/output ...\r\n", os.Args[0])
+		fmt.Println("\r\nThe palette format is taken from the file extension (.gpl, .act, .pal, .dat, .png)")
+		fmt.Println("unless it is given with -in or -out.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return flag.NArg() < 1
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const defaultGifDelay = 4 // in 100ths of a second
+
+// server serves the files of a source, drawn with one of the palettes
+type server struct {
+	source         *source
+	defaultPalette color.Palette // nil for the greyscale palette
+	palettes       map[string]color.Palette
+	paletteNames   []string
+}
+
+// httpError is an error that is answered with the given status code
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func (e *httpError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(err error) error {
+	return &httpError{status: http.StatusBadRequest, err: err}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", s.handle(s.index))
+	mux.HandleFunc("/view/", s.handle(s.view))
+	mux.HandleFunc("/png/", s.handle(s.framePNG))
+	mux.HandleFunc("/gif/", s.handle(s.directionGIF))
+	mux.HandleFunc("/sheet/", s.handle(s.sheet))
+	mux.HandleFunc("/info/", s.handle(s.info))
+
+	return mux
+}
+
+// handle turns the error of a handler into an error response
+func (s *server) handle(fn func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := fn(w, r)
+		if err == nil {
+			return
+		}
+
+		status := http.StatusInternalServerError
+
+		var statusErr *httpError
+
+		switch {
+		case errors.As(err, &statusErr):
+			status = statusErr.status
+		case errors.Is(err, errUnknownFile):
+			status = http.StatusNotFound
+		}
+
+		if status == http.StatusInternalServerError {
+			log.Printf("%s: %v", r.URL, err)
+		}
+
+		http.Error(w, err.Error(), status)
+	}
+}
+
+// load decodes the file named by the rest of the request path after the
+// prefix, with the palette picked by the pal query parameter. The requests are
+// handled at the same time, the dcc package serializes the decoding itself.
+func (s *server) load(r *http.Request, prefix string) (*dcc.DCC, error) {
+	name := strings.TrimPrefix(r.URL.Path, prefix)
+
+	data, err := s.source.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := dcc.FromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	p := s.defaultPalette
+
+	if palName := r.URL.Query().Get("pal"); palName != "" {
+		found := false
+		if p, found = s.palettes[palName]; !found {
+			return nil, badRequest(fmt.Errorf("unknown palette %q", palName))
+		}
+	}
+
+	d.SetPalette(p)
+
+	return d, nil
+}
+
+// drawMode returns the draw mode picked by the mode query parameter
+func drawMode(r *http.Request) (dcc.DrawMode, error) {
+	name := r.URL.Query().Get("mode")
+	if name == "" {
+		return dcc.DrawModeNormal, nil
+	}
+
+	mode, err := dcc.DrawModeFromString(name)
+	if err != nil {
+		return mode, badRequest(err)
+	}
+
+	return mode, nil
+}
+
+// queryInt returns the integer query parameter, or def if it is not given
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, badRequest(fmt.Errorf("%s must be a number, got %q", name, s))
+	}
+
+	return v, nil
+}
+
+// direction returns the direction picked by the dir query parameter
+func direction(r *http.Request, d *dcc.DCC) (*dcc.Direction, error) {
+	dirIdx, err := queryInt(r, "dir", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := d.Direction(dirIdx)
+	if dir == nil {
+		return nil, badRequest(fmt.Errorf("%w: %d", dcc.ErrDirectionOutOfRange, dirIdx))
+	}
+
+	return dir, nil
+}
+
+func (s *server) framePNG(w http.ResponseWriter, r *http.Request) error {
+	d, err := s.load(r, "/png/")
+	if err != nil {
+		return err
+	}
+
+	mode, err := drawMode(r)
+	if err != nil {
+		return err
+	}
+
+	dir, err := direction(r, d)
+	if err != nil {
+		return err
+	}
+
+	frameIdx, err := queryInt(r, "frame", 0)
+	if err != nil {
+		return err
+	}
+
+	img := dir.FrameImage(frameIdx, mode)
+	if img == nil {
+		return badRequest(fmt.Errorf("%w: %d", dcc.ErrFrameOutOfRange, frameIdx))
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+
+	return png.Encode(w, img)
+}
+
+func (s *server) directionGIF(w http.ResponseWriter, r *http.Request) error {
+	d, err := s.load(r, "/gif/")
+	if err != nil {
+		return err
+	}
+
+	mode, err := drawMode(r)
+	if err != nil {
+		return err
+	}
+
+	dir, err := direction(r, d)
+	if err != nil {
+		return err
+	}
+
+	delay, err := queryInt(r, "delay", defaultGifDelay)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+
+	return gif.EncodeAll(w, dir.GIF(mode, delay))
+}
+
+func (s *server) sheet(w http.ResponseWriter, r *http.Request) error {
+	d, err := s.load(r, "/sheet/")
+	if err != nil {
+		return err
+	}
+
+	mode, err := drawMode(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+
+	return png.Encode(w, d.Sheet(mode))
+}
+
+func (s *server) info(w http.ResponseWriter, r *http.Request) error {
+	d, err := s.load(r, "/info/")
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(d.Info())
+}
+
+// page is what the html templates are executed with
+type page struct {
+	Palette  string
+	Palettes []string
+	Names    []string
+	Name     string
+	Info     *dcc.Info
+}
+
+// URL returns the url of an endpoint for the named file, keeping the palette
+func (p *page) URL(endpoint, name string, params ...interface{}) string {
+	query := url.Values{}
+
+	if p.Palette != "" {
+		query.Set("pal", p.Palette)
+	}
+
+	for idx := 0; idx+1 < len(params); idx += 2 {
+		query.Set(fmt.Sprint(params[idx]), fmt.Sprint(params[idx+1]))
+	}
+
+	u := url.URL{Path: "/" + endpoint + "/" + name, RawQuery: query.Encode()}
+
+	return u.String()
+}
+
+func (s *server) newPage(r *http.Request) (*page, error) {
+	p := &page{
+		Palette:  r.URL.Query().Get("pal"),
+		Palettes: s.paletteNames,
+		Names:    s.source.names,
+	}
+
+	if _, found := s.palettes[p.Palette]; p.Palette != "" && !found {
+		return nil, badRequest(fmt.Errorf("unknown palette %q", p.Palette))
+	}
+
+	return p, nil
+}
+
+func (s *server) index(w http.ResponseWriter, r *http.Request) error {
+	if r.URL.Path != "/" {
+		return &httpError{status: http.StatusNotFound, err: fmt.Errorf("%w: %s", errUnknownFile, r.URL.Path)}
+	}
+
+	p, err := s.newPage(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	return pages.ExecuteTemplate(w, "index", p)
+}
+
+func (s *server) view(w http.ResponseWriter, r *http.Request) error {
+	p, err := s.newPage(r)
+	if err != nil {
+		return err
+	}
+
+	d, err := s.load(r, "/view/")
+	if err != nil {
+		return err
+	}
+
+	p.Name = strings.TrimPrefix(r.URL.Path, "/view/")
+	p.Info = d.Info()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	return pages.ExecuteTemplate(w, "view", p)
+}
+
+var pages = template.Must(template.New("pages").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Name}}{{.Name}}{{else}}dcc-serve{{end}}</title>
+<style>
+body { font-family: sans-serif; background: #333; color: #ddd; }
+a { color: #9cf; }
+img { image-rendering: pixelated; background: #555; }
+.files { display: flex; flex-wrap: wrap; gap: 8px; }
+.file { width: 160px; text-align: center; font-size: 12px; word-wrap: break-word; }
+.file img { max-width: 128px; max-height: 128px; min-width: 32px; }
+.directions img { margin: 4px; }
+</style>
+</head>
+<body>
+<form method="get">
+<a href="/{{if .Palette}}?pal={{.Palette}}{{end}}">all files</a>
+{{if .Palettes}}
+<select name="pal" onchange="this.form.submit()">
+<option value="">greyscale or -pal</option>
+{{range .Palettes}}<option{{if eq . $.Palette}} selected{{end}}>{{.}}</option>{{end}}
+</select>
+{{end}}
+</form>
+{{end}}
+
+{{define "index"}}{{template "head" .}}
+<p>{{len .Names}} files</p>
+<div class="files">
+{{range .Names}}<div class="file">
+<a href="{{$.URL "view" .}}"><img loading="lazy" src="{{$.URL "png" .}}" alt=""></a><br>
+<a href="{{$.URL "view" .}}">{{.}}</a>
+</div>
+{{end}}
+</div>
+</body>
+</html>
+{{end}}
+
+{{define "view"}}{{template "head" .}}
+<h2>{{.Name}}</h2>
+<p>
+{{.Info.NumberOfDirections}} directions, {{.Info.FramesPerDirection}} frames per direction
+&middot; <a href="{{.URL "sheet" .Name}}">sprite sheet</a>
+&middot; <a href="{{.URL "info" .Name}}">info</a>
+</p>
+<div class="directions">
+{{range $dir, $_ := .Info.Directions}}<a href="{{$.URL "gif" $.Name "dir" $dir}}"><img src="{{$.URL "gif" $.Name "dir" $dir}}" alt="direction {{$dir}}"></a>
+{{end}}
+</div>
+</body>
+</html>
+{{end}}
+`))
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const (
+	testDirections = 2
+	testFrames     = 3
+	testCopies     = 8
+)
+
+// newTestServer serves a directory with units/test.dcc and copies of it in
+// units/copies, and a red palette where palette index 1 is red
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	d := dcc.New()
+
+	for dir := 0; dir < testDirections; dir++ {
+		if err := d.AddDirection(&dcc.Direction{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for frame := 0; frame < testFrames; frame++ {
+		img := image.NewPaletted(image.Rect(-4, -8, 4, 0), nil)
+		for idx := range img.Pix {
+			img.Pix[idx] = 1
+		}
+
+		if err := d.Direction(0).InsertFrame(frame, dcc.NewFrame(img)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := d.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	write := func(name string, data []byte) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("units/test.dcc", data)
+
+	for idx := 0; idx < testCopies; idx++ {
+		write(fmt.Sprintf("units/copies/%d.dcc", idx), data)
+	}
+
+	red := append(color.Palette{}, *dcc.DefaultPalette()...)
+	red[1] = color.RGBA{R: 0xff, A: 0xff}
+
+	buf := &bytes.Buffer{}
+	if err := dcc.EncodePalette(buf, red, dcc.PaletteFormatGPL); err != nil {
+		t.Fatal(err)
+	}
+
+	write("palettes/red.gpl", buf.Bytes())
+
+	src, err := openSource(filepath.Join(root, "units"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &server{source: src}
+
+	if s.palettes, err = loadPalettes(filepath.Join(root, "palettes")); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+// get requests the path and checks the status code, the body is returned
+func get(t *testing.T, ts *httptest.Server, path string, status int) []byte {
+	t.Helper()
+
+	resp, err := http.Get(ts.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != status {
+		t.Fatalf("%s: expected status %d, got %d: %s", path, status, resp.StatusCode, body)
+	}
+
+	return body
+}
+
+func TestFramePNG(t *testing.T) {
+	ts := newTestServer(t)
+
+	img, err := png.Decode(bytes.NewReader(get(t, ts, "/png/test.dcc?dir=0&frame=2", http.StatusOK)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 8 {
+		t.Fatalf("expected an 8x8 frame, got %v", img.Bounds())
+	}
+
+	// the default palette is greyscale, the red palette makes index 1 red
+	r, g, _, _ := img.At(img.Bounds().Min.X, img.Bounds().Min.Y).RGBA()
+	if r != g {
+		t.Fatalf("expected a grey pixel, got %v", img.At(img.Bounds().Min.X, img.Bounds().Min.Y))
+	}
+
+	img, err = png.Decode(bytes.NewReader(get(t, ts, "/png/test.dcc?pal=red", http.StatusOK)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c := color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)); c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Fatalf("expected a red pixel with pal=red, got %v", c)
+	}
+
+	get(t, ts, "/png/test.dcc?frame=99", http.StatusBadRequest)
+	get(t, ts, "/png/test.dcc?dir=99", http.StatusBadRequest)
+	get(t, ts, "/png/test.dcc?pal=missing", http.StatusBadRequest)
+}
+
+func TestDirectionGIF(t *testing.T) {
+	ts := newTestServer(t)
+
+	g, err := gif.DecodeAll(bytes.NewReader(get(t, ts, "/gif/test.dcc?dir=1&delay=10&pal=red", http.StatusOK)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.Image) != testFrames || g.Delay[0] != 10 {
+		t.Fatalf("expected %d frames with a delay of 10, got %d frames, %v", testFrames, len(g.Image), g.Delay)
+	}
+}
+
+func TestSheet(t *testing.T) {
+	ts := newTestServer(t)
+
+	if _, err := png.Decode(bytes.NewReader(get(t, ts, "/sheet/test.dcc?mode=trans50", http.StatusOK))); err != nil {
+		t.Fatal(err)
+	}
+
+	get(t, ts, "/sheet/test.dcc?mode=sparkly", http.StatusBadRequest)
+}
+
+func TestInfo(t *testing.T) {
+	ts := newTestServer(t)
+
+	var info dcc.Info
+
+	if err := json.Unmarshal(get(t, ts, "/info/test.dcc", http.StatusOK), &info); err != nil {
+		t.Fatal(err)
+	}
+
+	if info.NumberOfDirections != testDirections || info.FramesPerDirection != testFrames {
+		t.Fatalf("expected %d directions of %d frames, got %d of %d",
+			testDirections, testFrames, info.NumberOfDirections, info.FramesPerDirection)
+	}
+}
+
+func TestUnknownFile(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, path := range []string{
+		"/png/missing.dcc",
+		"/gif/missing.dcc",
+		"/sheet/missing.dcc",
+		"/info/missing.dcc",
+		"/view/missing.dcc",
+		"/png/../units/test.dcc",
+		"/nothing-here",
+	} {
+		get(t, ts, path, http.StatusNotFound)
+	}
+
+	get(t, ts, "/", http.StatusOK)
+	get(t, ts, "/view/test.dcc?pal=red", http.StatusOK)
+}
+
+// the index page makes the browser request every file at once
+func TestConcurrentRequests(t *testing.T) {
+	ts := newTestServer(t)
+
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, 2*testCopies)
+
+	for idx := 0; idx < testCopies; idx++ {
+		for _, endpoint := range []string{"png", "info"} {
+			wg.Add(1)
+
+			go func(path string) {
+				defer wg.Done()
+
+				resp, err := http.Get(ts.URL + path)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				_ = resp.Body.Close()
+
+				if resp.StatusCode != http.StatusOK {
+					errs <- fmt.Errorf("%s: status %d", path, resp.StatusCode)
+				}
+			}(fmt.Sprintf("/%s/copies/%d.dcc?frame=1", endpoint, idx))
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/color"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+type options struct {
+	addr     *string
+	palPath  *string
+	palsPath *string
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	src, err := openSource(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer src.close()
+
+	s := &server{source: src}
+
+	if *o.palPath != "" {
+		if s.defaultPalette, err = loadPalette(*o.palPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *o.palsPath != "" {
+		if s.palettes, err = loadPalettes(*o.palsPath); err != nil {
+			log.Fatal(err)
+		}
+
+		for name := range s.palettes {
+			s.paletteNames = append(s.paletteNames, name)
+		}
+
+		sort.Strings(s.paletteNames)
+	}
+
+	httpServer := &http.Server{
+		Addr:              *o.addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	log.Printf("serving %d files from %s on http://%s/", len(src.names), flag.Arg(0), *o.addr)
+
+	if err := httpServer.ListenAndServe(); err != nil {
+		log.Print(err)
+	}
+}
+
+func loadPalette(palPath string) (color.Palette, error) {
+	format, err := dcc.PaletteFormatFromPath(palPath)
+	if err != nil {
+		return nil, err
+	}
+
+	palData, err := dcc.ReadFile(palPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read palette file, %w", err)
+	}
+
+	return dcc.DecodePalette(bytes.NewReader(palData), format)
+}
+
+// loadPalettes loads every palette file in the directory, named by their path
+// in the directory without the extension, like "act1/pal"
+func loadPalettes(dir string) (map[string]color.Palette, error) {
+	palettes := make(map[string]color.Palette)
+
+	err := filepath.Walk(dir, func(palPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		// files that are not palettes are skipped
+		if _, err := dcc.PaletteFormatFromPath(palPath); err != nil {
+			return nil
+		}
+
+		p, err := loadPalette(palPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", palPath, err)
+		}
+
+		rel, err := filepath.Rel(dir, palPath)
+		if err != nil {
+			return err
+		}
+
+		palettes[strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))] = p
+
+		return nil
+	})
+
+	return palettes, err
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.addr = flag.String("addr", "localhost:8080", "address to listen on")
+	o.palPath = flag.String("pal", "", "palette used when none is picked (optional, defaults to greyscale)")
+	o.palsPath = flag.String("pals", "", "directory of palettes that can be picked with the pal query parameter (optional)")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s [-addr localhost:8080] [-pals path/to/palettes] path/to/dir|archive.mpq[:path/in/archive]\r\n", os.Args[0])
+		fmt.Println("\r\nServes the dcc files in the directory or archive over http. Endpoints, which all take")
+		fmt.Println("a pal query parameter with the name of a palette in -pals, and a draw mode in mode:")
+		fmt.Println("\t/                              index with thumbnails")
+		fmt.Println("\t/png/<file>?dir=0&frame=0      a frame as png")
+		fmt.Println("\t/gif/<file>?dir=0&delay=4      a direction as gif")
+		fmt.Println("\t/sheet/<file>                  sprite sheet as png")
+		fmt.Println("\t/info/<file>                   info as json")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return flag.NArg() != 1
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+	"github.com/OpenDiablo2/dcc/pkg/mpq"
+)
+
+var errUnknownFile = errors.New("no such file")
+
+// source is the directory or the archive that the files are served from. Only
+// the files found when the source is opened are served, so a request can not
+// reach outside of it.
+type source struct {
+	archive *mpq.Archive
+	files   map[string]string // the served name of each file, and where it is read from
+	names   []string
+}
+
+// openSource finds the dcc files in a directory, or in an archive given as
+// "archive.mpq" or "archive.mpq:path/in/archive"
+func openSource(sourcePath string) (*source, error) {
+	archivePath, dir, isArchive := dcc.SplitArchivePath(sourcePath)
+	if !isArchive && strings.EqualFold(filepath.Ext(sourcePath), ".mpq") {
+		archivePath, dir, isArchive = sourcePath, "", true
+	}
+
+	s := &source{files: make(map[string]string)}
+
+	var err error
+
+	if isArchive {
+		err = s.findInArchive(archivePath, dir)
+	} else {
+		err = s.findInDirectory(sourcePath)
+	}
+
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+
+	for name := range s.files {
+		s.names = append(s.names, name)
+	}
+
+	sort.Strings(s.names)
+
+	return s, nil
+}
+
+func (s *source) findInDirectory(root string) error {
+	return filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !isDCC(filePath) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+
+		s.files[filepath.ToSlash(rel)] = filePath
+
+		return nil
+	})
+}
+
+func (s *source) findInArchive(archivePath, dir string) error {
+	archive, err := mpq.Open(archivePath)
+	if err != nil {
+		return err
+	}
+
+	s.archive = archive
+
+	listed, err := archive.Listfile()
+	if err != nil {
+		return fmt.Errorf("could not search %s, %w", archivePath, err)
+	}
+
+	prefix := strings.ToLower(strings.Trim(strings.ReplaceAll(dir, "\\", "/"), "/"))
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	for _, listedName := range listed {
+		name := strings.ReplaceAll(listedName, "\\", "/")
+
+		if !strings.HasPrefix(strings.ToLower(name), prefix) || !isDCC(name) || !archive.Contains(listedName) {
+			continue
+		}
+
+		s.files[name[len(prefix):]] = listedName
+	}
+
+	return nil
+}
+
+func isDCC(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".dcc")
+}
+
+// readFile reads the file with the served name
+func (s *source) readFile(name string) ([]byte, error) {
+	location, found := s.files[name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", errUnknownFile, name)
+	}
+
+	if s.archive != nil {
+		return s.archive.ReadFile(location)
+	}
+
+	return ioutil.ReadFile(location)
+}
+
+func (s *source) close() {
+	if s.archive != nil {
+		_ = s.archive.Close()
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+type options struct {
+	dccPath    *string
+	outPath    *string
+	frames     *string
+	directions *string
+}
+
+func main() {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(&o); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run(o *options) error {
+	d, err := dcc.FromFile(*o.dccPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *o.dccPath, err)
+	}
+
+	if *o.directions != "" {
+		directions, err := parseList(*o.directions)
+		if err != nil {
+			return err
+		}
+
+		if d, err = d.ExtractDirections(directions...); err != nil {
+			return err
+		}
+	}
+
+	parts := []*dcc.DCC{d}
+
+	if *o.frames != "" {
+		ranges, err := parseRanges(*o.frames)
+		if err != nil {
+			return err
+		}
+
+		if parts, err = d.Split(ranges...); err != nil {
+			return err
+		}
+	}
+
+	outPath := *o.outPath
+	if len(parts) > 1 {
+		outPath = fileNameWithoutExt(outPath) + "_%v" + filepath.Ext(outPath)
+	}
+
+	for idx, part := range parts {
+		path := outPath
+		if len(parts) > 1 {
+			path = fmt.Sprintf(outPath, idx)
+		}
+
+		data, err := part.Encode()
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(path, data, 0o644); err != nil { //nolint:gomnd // file permissions
+			return fmt.Errorf("could not write file, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// parseList parses comma separated numbers, like 0,2,4
+func parseList(s string) ([]int, error) {
+	fields := strings.Split(s, ",")
+	list := make([]int, len(fields))
+
+	for idx, field := range fields {
+		if _, err := fmt.Sscanf(field, "%d", &list[idx]); err != nil {
+			return nil, fmt.Errorf("expecting a number, got %q", field)
+		}
+	}
+
+	return list, nil
+}
+
+// parseRanges parses comma separated inclusive frame ranges, like 0-3,4-7 or 2
+func parseRanges(s string) ([]dcc.FrameRange, error) {
+	fields := strings.Split(s, ",")
+	ranges := make([]dcc.FrameRange, len(fields))
+
+	for idx, field := range fields {
+		var first, last int
+
+		if _, err := fmt.Sscanf(field, "%d-%d", &first, &last); err != nil {
+			if _, err := fmt.Sscanf(field, "%d", &first); err != nil {
+				return nil, fmt.Errorf("expecting a frame range like 0-3, got %q", field)
+			}
+
+			last = first
+		}
+
+		ranges[idx] = dcc.FrameRange{Start: first, End: last + 1}
+	}
+
+	return ranges, nil
+}
+
+func fileNameWithoutExt(fileName string) string {
+	return fileName[:len(fileName)-len(filepath.Ext(fileName))]
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.dccPath = flag.String("dcc", "", "input dcc file, or archive.mpq:path/in/archive (required)")
+	o.outPath = flag.String("out", "", "output dcc file, numbered when there are several frame ranges (required)")
+	o.frames = flag.String("frames", "", "frame ranges to split into, like 0-3,4-7 (optional)")
+	o.directions = flag.String("dirs", "", "directions to keep, like 0,2,4 (optional)")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s -dcc in.dcc -out out.dcc [-frames 0-3,4-7] [-dirs 0,2,4]\r\n", os.Args[0])
+		fmt.Println("\r\nThe frame ranges include both ends. Offsets and anchors are kept.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return *o.dccPath == "" || *o.outPath == ""
+}
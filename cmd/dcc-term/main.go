@@ -0,0 +1,486 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const (
+	refreshRate = 20 * time.Millisecond
+	maxSpeed    = 16
+	minSpeed    = 1.0 / maxSpeed
+)
+
+const (
+	clearScreen       = "\x1b[2J"
+	cursorHome        = "\x1b[H"
+	clearLine         = "\x1b[K"
+	hideCursor        = "\x1b[?25l"
+	showCursor        = "\x1b[?25h"
+	deleteKittyImages = "\x1b_Ga=d,q=2\x1b\\"
+)
+
+type options struct {
+	dccPath  *string
+	palPath  *string
+	drawMode *string
+	render   *string
+	dir      *int
+	frame    *int
+	scale    *float64
+	delay    *int
+	playMode *string
+	once     *bool
+}
+
+type key int
+
+const (
+	keyNone key = iota
+	keyQuit
+	keyPlay
+	keyNextFrame
+	keyPrevFrame
+	keyNextDirection
+	keyPrevDirection
+	keyPlayMode
+	keyRepeat
+	keyFaster
+	keySlower
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var o options
+
+	if showUsage := parseOptions(&o); showUsage {
+		flag.Usage()
+		return 1
+	}
+
+	v, err := newViewer(&o)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	var restore func()
+
+	// without a terminal to read keys from, like on a build server, the frame is drawn once
+	if !*o.once {
+		restore, err = rawMode()
+	}
+
+	if *o.once || err != nil {
+		if err := v.drawFrame(os.Stdout); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+
+		return 0
+	}
+
+	defer restore()
+
+	if err := v.run(); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	return 0
+}
+
+type viewer struct {
+	dcc      *dcc.DCC
+	animator *dcc.Animator
+	drawMode dcc.DrawMode
+	render   dcc.TerminalMode
+	order    []int // the directions in clockwise order
+	dirSlot  int   // the position of the current direction in order
+}
+
+func newViewer(o *options) (*viewer, error) {
+	drawMode, err := dcc.DrawModeFromString(*o.drawMode)
+	if err != nil {
+		return nil, err
+	}
+
+	render, err := dcc.TerminalModeFromString(*o.render)
+	if err != nil {
+		return nil, err
+	}
+
+	playMode, err := dcc.PlayModeFromString(*o.playMode)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := load(*o.dccPath, *o.palPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.Directions()) == 0 {
+		return nil, fmt.Errorf("%s has no directions", *o.dccPath)
+	}
+
+	if *o.scale != 1 {
+		if err := d.Scale(dcc.ScaleNearest, *o.scale); err != nil {
+			return nil, err
+		}
+	}
+
+	v := &viewer{
+		dcc:      d,
+		animator: dcc.NewAnimator(d),
+		drawMode: drawMode,
+		render:   render,
+	}
+
+	v.order, err = dcc.DirectionOrder(len(d.Directions()))
+	if err != nil {
+		// unusual direction counts are shown in file order
+		v.order = make([]int, len(d.Directions()))
+		for idx := range v.order {
+			v.order[idx] = idx
+		}
+	}
+
+	for slot, dir := range v.order {
+		if dir == *o.dir {
+			v.dirSlot = slot
+		}
+	}
+
+	v.animator.SetRepeat(true)
+	v.animator.SetPlayMode(playMode)
+	v.animator.SetFrameDuration(time.Duration(*o.delay) * time.Millisecond)
+
+	if err := v.animator.SetDirection(*o.dir); err != nil {
+		return nil, err
+	}
+
+	if err := v.animator.SetFrame(*o.frame); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func load(path, palPath string) (*dcc.DCC, error) {
+	d, err := dcc.FromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if palPath == "" {
+		return d, nil
+	}
+
+	format, err := dcc.PaletteFormatFromPath(palPath)
+	if err != nil {
+		return nil, err
+	}
+
+	palData, err := dcc.ReadFile(palPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read palette file, %w", err)
+	}
+
+	p, err := dcc.DecodePalette(bytes.NewReader(palData), format)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetPalette(p)
+
+	return d, nil
+}
+
+// drawFrame draws the current frame at the cursor
+func (v *viewer) drawFrame(w io.Writer) error {
+	img := v.dcc.Direction(v.animator.Direction()).FrameImage(v.animator.Frame(), v.drawMode)
+	if img == nil {
+		return nil
+	}
+
+	return dcc.WriteTerminalImage(w, img, v.render)
+}
+
+// run shows the animation until the user quits, the terminal must be in raw mode
+func (v *viewer) run() error {
+	keys := make(chan key)
+	go readKeys(os.Stdin, keys)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	defer signal.Stop(interrupt)
+
+	fmt.Print(hideCursor + clearScreen)
+	defer fmt.Print(showCursor + "\n")
+
+	ticker := time.NewTicker(refreshRate)
+	defer ticker.Stop()
+
+	v.animator.Play()
+
+	last := time.Now()
+	redraw, clear := true, false
+
+	for {
+		if redraw {
+			if err := v.draw(clear); err != nil {
+				return err
+			}
+		}
+
+		frame, dir := v.animator.Frame(), v.animator.Direction()
+
+		select {
+		case <-interrupt:
+			return nil
+		case now := <-ticker.C:
+			v.animator.Update(now.Sub(last))
+			last = now
+			redraw, clear = v.animator.Frame() != frame, false
+		case k := <-keys:
+			if k == keyQuit {
+				return nil
+			}
+
+			v.handleKey(k)
+
+			redraw, clear = true, v.animator.Direction() != dir
+		}
+	}
+}
+
+func (v *viewer) handleKey(k key) {
+	numFrames := len(v.dcc.Direction(v.animator.Direction()).Frames())
+
+	switch k {
+	case keyPlay:
+		if v.animator.IsPlaying() {
+			v.animator.Pause()
+		} else {
+			v.animator.Play()
+		}
+	case keyNextFrame, keyPrevFrame:
+		step := 1
+		if k == keyPrevFrame {
+			step = -1
+		}
+
+		v.animator.Pause()
+
+		if numFrames > 0 {
+			_ = v.animator.SetFrame((v.animator.Frame() + step + numFrames) % numFrames)
+		}
+	case keyNextDirection, keyPrevDirection:
+		step := 1
+		if k == keyPrevDirection {
+			step = -1
+		}
+
+		v.dirSlot = (v.dirSlot + step + len(v.order)) % len(v.order)
+		_ = v.animator.SetDirection(v.order[v.dirSlot])
+	case keyPlayMode:
+		v.animator.SetPlayMode((v.animator.PlayMode() + 1) % (dcc.PlayPingPong + 1))
+	case keyRepeat:
+		v.animator.SetRepeat(!v.animator.Repeat())
+	case keyFaster:
+		if speed := v.animator.Speed() * 2; speed <= maxSpeed {
+			v.animator.SetSpeed(speed)
+		}
+	case keySlower:
+		if speed := v.animator.Speed() / 2; speed >= minSpeed {
+			v.animator.SetSpeed(speed)
+		}
+	}
+}
+
+// draw redraws the screen, images that do not cover the previous one need it cleared first
+func (v *viewer) draw(clear bool) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString(cursorHome)
+
+	switch {
+	case v.render == dcc.TerminalKitty:
+		buf.WriteString(deleteKittyImages + clearScreen)
+	case v.render == dcc.TerminalSixel, clear:
+		buf.WriteString(clearScreen)
+	}
+
+	if err := v.drawFrame(buf); err != nil {
+		return err
+	}
+
+	state := "paused"
+	if v.animator.IsPlaying() {
+		state = "playing"
+	}
+
+	numFrames := len(v.dcc.Direction(v.animator.Direction()).Frames())
+
+	fmt.Fprintf(buf, "direction %d/%d  frame %d/%d  %s %s  speed x%g  repeat %v%s\n",
+		v.animator.Direction(), len(v.dcc.Directions()), v.animator.Frame(), numFrames,
+		state, v.animator.PlayMode(), v.animator.Speed(), v.animator.Repeat(), clearLine)
+	fmt.Fprintf(buf, "%s%s",
+		"space play/pause  left/right frame  up/down direction  p mode  r repeat  +/- speed  q quit", clearLine)
+
+	_, err := os.Stdout.Write(buf.Bytes())
+
+	return err
+}
+
+// readKeys sends the keys read from r until it fails
+func readKeys(r io.Reader, keys chan<- key) {
+	buf := make([]byte, 64) //nolint:gomnd // a few keys pressed at once
+
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			keys <- keyQuit
+			return
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// parseKeys returns the keys in the bytes read from the terminal, unknown keys
+// are skipped. The arrow keys are sent as ESC [ x, or as ESC O x when the
+// terminal is in application mode. Escape itself is only taken as a key when it
+// is the last byte read, otherwise it starts a sequence of a key that is not known.
+func parseKeys(b []byte) []key {
+	arrows := map[byte]key{
+		'A': keyNextDirection,
+		'B': keyPrevDirection,
+		'C': keyNextFrame,
+		'D': keyPrevFrame,
+	}
+
+	const (
+		escape       = '\x1b'
+		escapeLength = 3
+	)
+
+	result := make([]key, 0, len(b))
+
+	for len(b) > 0 {
+		switch {
+		case b[0] != escape:
+			if k := parseKey(b[0]); k != keyNone {
+				result = append(result, k)
+			}
+		case len(b) == 1:
+			result = append(result, keyQuit)
+		case len(b) >= escapeLength && (b[1] == '[' || b[1] == 'O'):
+			if k, found := arrows[b[2]]; found {
+				result = append(result, k)
+			}
+
+			b = b[escapeLength:]
+
+			continue
+		}
+
+		b = b[1:]
+	}
+
+	return result
+}
+
+func parseKey(c byte) key {
+	switch c {
+	case 'q', 'Q':
+		return keyQuit
+	case ' ':
+		return keyPlay
+	case 'l':
+		return keyNextFrame
+	case 'h':
+		return keyPrevFrame
+	case 'k':
+		return keyNextDirection
+	case 'j':
+		return keyPrevDirection
+	case 'p':
+		return keyPlayMode
+	case 'r':
+		return keyRepeat
+	case '+', '=':
+		return keyFaster
+	case '-', '_':
+		return keySlower
+	default:
+		return keyNone
+	}
+}
+
+// rawMode makes the terminal send every key as soon as it is pressed, without
+// echoing it, and returns a function that restores the previous settings
+func rawMode() (restore func(), err error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the terminal settings, %w", err)
+	}
+
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("could not change the terminal settings, %w", err)
+	}
+
+	return func() { _, _ = stty(strings.TrimSpace(saved)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+
+	return string(out), err
+}
+
+func parseOptions(o *options) (terminate bool) {
+	o.dccPath = flag.String("dcc", "", "input dcc file, or archive.mpq:path/in/archive (required)")
+	o.palPath = flag.String("pal", "", "palette file (optional, defaults to greyscale)")
+	o.drawMode = flag.String("mode", "normal", "draw mode: normal, trans25, trans50, trans75, additive, luminance")
+	o.render = flag.String("render", "ansi", "how the frames are drawn: ansi (half blocks), sixel, kitty")
+	o.dir = flag.Int("dir", 0, "direction to show first")
+	o.frame = flag.Int("frame", 0, "frame to show first")
+	o.scale = flag.Float64("scale", 1, "scale factor of the frames")
+	o.delay = flag.Int("delay", int(dcc.DefaultFrameDuration/time.Millisecond), "time between frames, in milliseconds")
+	o.playMode = flag.String("play", "forward", "play mode: forward, backward, ping-pong")
+	o.once = flag.Bool("once", false, "draw the frame once and exit, this is the default when stdin is not a terminal")
+
+	flag.Usage = func() {
+		fmt.Printf("Usage:\r\n\t%s -dcc path/to/file.dcc [-render ansi|sixel|kitty] [-once]\r\n", os.Args[0])
+		fmt.Println("\r\nShows the animation in the terminal. Keys: space plays or pauses, left and right")
+		fmt.Println("step through the frames, up and down rotate the direction, p changes the play mode,")
+		fmt.Println("r toggles repeat, + and - change the speed, q quits.")
+		fmt.Println("\r\nThe interactive viewer needs a Unix terminal, as it sets the terminal up with stty.")
+		fmt.Println("Elsewhere, or when stdin is not a terminal, the frame is drawn once.")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	return *o.dccPath == ""
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		keys  []key
+	}{
+		{" pq", []key{keyPlay, keyPlayMode, keyQuit}},
+		{"\x1b[A\x1b[B\x1b[C\x1b[D", []key{keyNextDirection, keyPrevDirection, keyNextFrame, keyPrevFrame}},
+		{"\x1bOA\x1bOD", []key{keyNextDirection, keyPrevFrame}},
+		{"\x1b", []key{keyQuit}},
+		{"l\x1b", []key{keyNextFrame, keyQuit}},
+		// an escape in the middle starts a key that is not known
+		{"\x1b[Hl", []key{keyNextFrame}},
+		{"\x1bxl", []key{keyNextFrame}},
+		{"\x1b\x1b[C", []key{keyNextFrame}},
+		{"zz", []key{}},
+	} {
+		if got := parseKeys([]byte(test.input)); !reflect.DeepEqual(got, test.keys) {
+			t.Fatalf("%q: expected %v, got %v", test.input, test.keys, got)
+		}
+	}
+}
@@ -4,13 +4,9 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
-	"image/color"
-	"io/ioutil"
 
 	"github.com/AllenDang/giu"
 
-	gpl "github.com/gravestench/gpl/pkg"
-
 	dccLib "github.com/OpenDiablo2/dcc/pkg"
 	dccWidget "github.com/OpenDiablo2/dcc/pkg/giuwidget"
 )
@@ -30,7 +26,7 @@ func main() {
 		return
 	}
 
-	fileContents, err := ioutil.ReadFile(*o.dccPath)
+	fileContents, err := dccLib.ReadFile(*o.dccPath)
 	if err != nil {
 		const fmtErr = "could not read file, %w"
 
@@ -46,19 +42,25 @@ func main() {
 	}
 
 	if *o.palPath != "" {
-		palData, err := ioutil.ReadFile(*o.palPath)
+		format, err := dccLib.PaletteFormatFromPath(*o.palPath)
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
 
-		gplInstance, err := gpl.Decode(bytes.NewBuffer(palData))
+		palData, err := dccLib.ReadFile(*o.palPath)
 		if err != nil {
-			fmt.Println("palette is not a GIMP palette file...")
+			fmt.Println(err)
+			return
+		}
+
+		p, err := dccLib.DecodePalette(bytes.NewReader(palData), format)
+		if err != nil {
+			fmt.Println(err)
 			return
 		}
 
-		dcc.SetPalette(color.Palette(gplInstance))
+		dcc.SetPalette(p)
 	} else {
 		dcc.SetPalette(nil)
 	}
@@ -79,8 +81,8 @@ type options struct {
 }
 
 func parseOptions(o *options) (terminate bool) {
-	o.dccPath = flag.String("dcc", "", "input dcc file (required)")
-	o.palPath = flag.String("pal", "", "input pal file (optional)")
+	o.dccPath = flag.String("dcc", "", "input dcc file, or archive.mpq:path/in/archive (required)")
+	o.palPath = flag.String("pal", "", "input pal file, or archive.mpq:path/in/archive (optional)")
 	o.pngPath = flag.String("png", "", "path to png file (optional)")
 
 	flag.Parse()
@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"fmt"
+	"image"
+)
+
+// DefaultMaxAlignShift is the largest shift, in pixels along each axis, that is tried when aligning
+const DefaultMaxAlignShift = 16
+
+// Alignment is the shift that lines the frames of a direction up with those of
+// a reference direction, found by matching the opaque pixels of the frames
+type Alignment struct {
+	Direction int         `json:"direction"`
+	Shift     image.Point `json:"shift"`
+	// Overlap is the number of opaque pixels that are also opaque in the
+	// reference after the shift, summed over the frames
+	Overlap int `json:"overlap"`
+	// Opaque is the number of opaque pixels, summed over the frames
+	Opaque int `json:"opaque"`
+}
+
+// Match returns the fraction of the opaque pixels that overlap the reference
+func (a Alignment) Match() float64 {
+	if a.Opaque == 0 {
+		return 0
+	}
+
+	return float64(a.Overlap) / float64(a.Opaque)
+}
+
+// opaqueMask is the set of opaque pixels of a frame
+type opaqueMask struct {
+	bounds image.Rectangle
+	pixels []bool
+	points []image.Point
+}
+
+func newOpaqueMask(f *Frame) *opaqueMask {
+	m := &opaqueMask{bounds: f.Box, pixels: make([]bool, f.Box.Dx()*f.Box.Dy())}
+
+	for y := f.Box.Min.Y; y < f.Box.Max.Y; y++ {
+		for x := f.Box.Min.X; x < f.Box.Max.X; x++ {
+			if f.ColorIndexAt(x, y) != 0 {
+				m.pixels[(x-f.Box.Min.X)+(y-f.Box.Min.Y)*f.Box.Dx()] = true
+				m.points = append(m.points, image.Pt(x, y))
+			}
+		}
+	}
+
+	return m
+}
+
+func (m *opaqueMask) opaque(p image.Point) bool {
+	if !p.In(m.bounds) {
+		return false
+	}
+
+	return m.pixels[(p.X-m.bounds.Min.X)+(p.Y-m.bounds.Min.Y)*m.bounds.Dx()]
+}
+
+// overlap counts the opaque pixels of m that land on opaque pixels of the
+// reference when shifted
+func (m *opaqueMask) overlap(reference *opaqueMask, shift image.Point) int {
+	count := 0
+
+	for _, p := range m.points {
+		if reference.opaque(p.Add(shift)) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// EstimateAlignment finds the shift, up to maxShift pixels along each axis,
+// that makes the opaque pixels of the frames overlap those of the reference
+// direction the most. This is the peak of the cross-correlation of the alpha
+// masks. Frames are paired by their position in the animation, so the
+// directions can have a different number of frames. Of equally good shifts,
+// the smallest one is used. When either direction has no frames, the zero
+// alignment is returned.
+func (d *Direction) EstimateAlignment(reference *Direction, maxShift int) Alignment {
+	if len(d.frames) == 0 || len(reference.frames) == 0 {
+		return Alignment{}
+	}
+
+	masks := make([]*opaqueMask, len(d.frames))
+	references := make([]*opaqueMask, len(d.frames))
+	best := Alignment{Overlap: -1}
+
+	for idx, f := range d.frames {
+		masks[idx] = newOpaqueMask(f)
+		references[idx] = newOpaqueMask(reference.frames[idx*len(reference.frames)/len(d.frames)])
+		best.Opaque += len(masks[idx].points)
+	}
+
+	for dy := -maxShift; dy <= maxShift; dy++ {
+		for dx := -maxShift; dx <= maxShift; dx++ {
+			shift := image.Pt(dx, dy)
+			overlap := 0
+
+			for idx := range masks {
+				overlap += masks[idx].overlap(references[idx], shift)
+			}
+
+			if overlap > best.Overlap || (overlap == best.Overlap && manhattan(shift) < manhattan(best.Shift)) {
+				best.Shift, best.Overlap = shift, overlap
+			}
+		}
+	}
+
+	return best
+}
+
+func manhattan(p image.Point) int {
+	return abs(p.X) + abs(p.Y)
+}
+
+// EstimateAlignment estimates the alignment of every direction against the
+// same direction of the reference, see Direction.EstimateAlignment. The dccs
+// must have the same number of directions.
+func (d *DCC) EstimateAlignment(reference *DCC, maxShift int) ([]Alignment, error) {
+	if len(d.directions) != len(reference.directions) {
+		const fmtErr = "%w: has %d, the reference has %d"
+		return nil, fmt.Errorf(fmtErr, ErrDirectionCountMismatch, len(d.directions), len(reference.directions))
+	}
+
+	alignments := make([]Alignment, len(d.directions))
+
+	for idx, dir := range d.directions {
+		alignments[idx] = dir.EstimateAlignment(reference.directions[idx], maxShift)
+		alignments[idx].Direction = idx
+	}
+
+	return alignments, nil
+}
+
+// ApplyAlignment shifts the offsets of the directions by the alignments
+func (d *DCC) ApplyAlignment(alignments []Alignment) error {
+	for _, a := range alignments {
+		dir := d.Direction(a.Direction)
+		if dir == nil {
+			return fmt.Errorf("%w: %d", ErrDirectionOutOfRange, a.Direction)
+		}
+
+		dir.ShiftOffsets(a.Shift.X, a.Shift.Y)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"image"
+	"testing"
+)
+
+// silhouette returns a figure without symmetries, a head above a body with
+// one arm, moved by offset
+func silhouette(offset image.Point) *image.Paletted {
+	head := image.Rect(-3, -30, 3, -24)
+	body := image.Rect(-5, -24, 5, 0)
+	arm := image.Rect(5, -22, 11, -18)
+
+	img := image.NewPaletted(head.Union(body).Union(arm).Add(offset), nil)
+
+	for _, part := range []image.Rectangle{head, body, arm} {
+		part = part.Add(offset)
+
+		for y := part.Min.Y; y < part.Max.Y; y++ {
+			for x := part.Min.X; x < part.Max.X; x++ {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+func TestEstimateAlignment(t *testing.T) {
+	offset := image.Pt(-3, 5)
+
+	reference := newTestDCC(t, []*image.Paletted{silhouette(image.Point{}), silhouette(image.Pt(1, 0))})
+	d := newTestDCC(t, []*image.Paletted{silhouette(offset), silhouette(offset.Add(image.Pt(1, 0)))})
+
+	alignments, err := d.EstimateAlignment(reference, DefaultMaxAlignShift)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := alignments[0]
+
+	if want := offset.Mul(-1); a.Shift != want {
+		t.Fatalf("expected shift %v, got %v", want, a.Shift)
+	}
+
+	if a.Match() != 1 {
+		t.Fatalf("expected every opaque pixel to overlap, got %v", a.Match())
+	}
+
+	if err := d.ApplyAlignment(alignments); err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, f := range d.Direction(0).Frames() {
+		if want := reference.Direction(0).Frame(idx).Box; f.Box != want {
+			t.Fatalf("frame %d, expected box %v after aligning, got %v", idx, want, f.Box)
+		}
+	}
+}
+
+func TestEstimateAlignmentWithoutFrames(t *testing.T) {
+	reference := newTestDCC(t, []*image.Paletted{})
+	d := newTestDCC(t, []*image.Paletted{silhouette(image.Point{})})
+
+	if a := d.Direction(0).EstimateAlignment(reference.Direction(0), DefaultMaxAlignShift); a != (Alignment{}) {
+		t.Fatalf("expected the zero alignment, got %+v", a)
+	}
+
+	if a := reference.Direction(0).EstimateAlignment(d.Direction(0), DefaultMaxAlignShift); a != (Alignment{}) {
+		t.Fatalf("expected the zero alignment, got %+v", a)
+	}
+}
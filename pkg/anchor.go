@@ -0,0 +1,158 @@
+package pkg
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// AnchorMode selects the point of the opaque pixels that Recenter moves to the origin
+type AnchorMode int
+
+// Anchor modes
+const (
+	// AnchorFeet puts the origin at the horizontal center of the bottom row of
+	// opaque pixels, where the feet of a character stand on the tile
+	AnchorFeet AnchorMode = iota
+	// AnchorCenter puts the origin at the center of the opaque pixels
+	AnchorCenter
+)
+
+var anchorModeNames = map[AnchorMode]string{
+	AnchorFeet:   "feet",
+	AnchorCenter: "center",
+}
+
+func (m AnchorMode) String() string {
+	s, ok := anchorModeNames[m]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// AnchorModeFromString returns the anchor mode with the given name, as
+// yielded by AnchorMode.String
+func AnchorModeFromString(s string) (AnchorMode, error) {
+	for mode, name := range anchorModeNames {
+		if strings.EqualFold(name, s) {
+			return mode, nil
+		}
+	}
+
+	return AnchorFeet, fmt.Errorf("unknown anchor mode %q", s)
+}
+
+// point returns the anchor point of the given opaque bounds
+func (m AnchorMode) point(r image.Rectangle) image.Point {
+	const half = 2
+
+	center := image.Pt(r.Min.X+(r.Dx()-1)/half, r.Min.Y+(r.Dy()-1)/half)
+
+	if m == AnchorCenter {
+		return center
+	}
+
+	return image.Pt(center.X, r.Max.Y-1)
+}
+
+// OpaqueBounds returns the smallest rectangle that holds every pixel of the frame
+// that is not transparent, or an empty rectangle if the frame is fully transparent
+func (f *Frame) OpaqueBounds() image.Rectangle {
+	bounds := image.Rectangle{}
+
+	for y := f.Box.Min.Y; y < f.Box.Max.Y; y++ {
+		for x := f.Box.Min.X; x < f.Box.Max.X; x++ {
+			if f.ColorIndexAt(x, y) != 0 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	return bounds
+}
+
+// OpaqueBounds returns the union of the opaque bounds of the frames
+func (d *Direction) OpaqueBounds() image.Rectangle {
+	bounds := image.Rectangle{}
+
+	for _, f := range d.frames {
+		bounds = bounds.Union(f.OpaqueBounds())
+	}
+
+	return bounds
+}
+
+// OpaqueBounds returns the union of the opaque bounds of the directions
+func (d *DCC) OpaqueBounds() image.Rectangle {
+	bounds := image.Rectangle{}
+
+	for _, dir := range d.directions {
+		bounds = bounds.Union(dir.OpaqueBounds())
+	}
+
+	return bounds
+}
+
+// ShiftOffsets moves every frame of the direction by dx, dy relative to the
+// origin. The frame boxes and the direction box are updated to match.
+func (d *Direction) ShiftOffsets(dx, dy int) {
+	delta := image.Pt(dx, dy)
+
+	d.edit(func() {
+		for _, f := range d.frames {
+			f.XOffset += dx
+			f.YOffset += dy
+			f.Box = f.Box.Add(delta)
+		}
+	})
+}
+
+// ShiftOffsets moves every frame of every direction by dx, dy, see Direction.ShiftOffsets
+func (d *DCC) ShiftOffsets(dx, dy int) {
+	for _, dir := range d.directions {
+		dir.ShiftOffsets(dx, dy)
+	}
+}
+
+// SetAnchor shifts the frames so that the pixel at p lands on the origin
+func (d *Direction) SetAnchor(p image.Point) {
+	d.ShiftOffsets(-p.X, -p.Y)
+}
+
+// SetAnchor shifts the frames of every direction so that the pixel at p lands on the origin
+func (d *DCC) SetAnchor(p image.Point) {
+	d.ShiftOffsets(-p.X, -p.Y)
+}
+
+// Recenter moves the anchor point of the opaque pixels of all frames to the
+// origin, and returns the shift that was applied. Fully transparent directions
+// are left alone.
+func (d *Direction) Recenter(mode AnchorMode) image.Point {
+	bounds := d.OpaqueBounds()
+	if bounds.Empty() {
+		return image.Point{}
+	}
+
+	shift := image.Point{}.Sub(mode.point(bounds))
+	d.ShiftOffsets(shift.X, shift.Y)
+
+	return shift
+}
+
+// Recenter moves the anchor point of the opaque pixels of the whole file to the
+// origin, and returns the shift that was applied. Every direction is shifted by
+// the same amount, so the sprite does not jump when it changes direction; use
+// Direction.Recenter to recenter the directions separately.
+func (d *DCC) Recenter(mode AnchorMode) image.Point {
+	bounds := d.OpaqueBounds()
+	if bounds.Empty() {
+		return image.Point{}
+	}
+
+	shift := image.Point{}.Sub(mode.point(bounds))
+	d.ShiftOffsets(shift.X, shift.Y)
+
+	return shift
+}
@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"image"
+	"testing"
+)
+
+// paddedImage returns an image with numbered pixels inside opaque, and
+// transparent pixels around them up to the bounds
+func paddedImage(bounds, opaque image.Rectangle) *image.Paletted {
+	img := image.NewPaletted(bounds, nil)
+	inner := numberedImage(opaque)
+
+	for y := opaque.Min.Y; y < opaque.Max.Y; y++ {
+		for x := opaque.Min.X; x < opaque.Max.X; x++ {
+			img.SetColorIndex(x, y, inner.ColorIndexAt(x, y))
+		}
+	}
+
+	return img
+}
+
+// checkShifted checks that every frame was moved by shift, pixels and all.
+// Directions without frames in before are not checked.
+func checkShifted(t *testing.T, d *DCC, before [][]*image.Paletted, shift image.Point) {
+	t.Helper()
+
+	for dir, frames := range before {
+		if frames == nil {
+			continue
+		}
+
+		box := image.Rectangle{}
+
+		for frame, img := range frames {
+			f := d.Direction(dir).Frame(frame)
+			want := image.NewPaletted(img.Rect.Add(shift), nil)
+			copy(want.Pix, img.Pix)
+
+			checkFrame(t, "shifted", f, want)
+
+			if f.XOffset != want.Rect.Min.X || f.YOffset != want.Rect.Max.Y-1 {
+				t.Fatalf("expected the offsets %d,%d, got %d,%d", want.Rect.Min.X, want.Rect.Max.Y-1, f.XOffset, f.YOffset)
+			}
+
+			box = box.Union(want.Rect)
+		}
+
+		if *d.Direction(dir).Box != box {
+			t.Fatalf("direction %d, expected the box %v, got %v", dir, box, *d.Direction(dir).Box)
+		}
+	}
+}
+
+func anchorTestDCC(t *testing.T) (*DCC, [][]*image.Paletted) {
+	t.Helper()
+
+	directions := [][]*image.Paletted{
+		{paddedImage(image.Rect(10, 20, 30, 50), image.Rect(14, 25, 21, 44)), paddedImage(image.Rect(8, 28, 20, 48), image.Rect(12, 30, 19, 46))},
+		{paddedImage(image.Rect(15, 20, 25, 40), image.Rect(16, 26, 20, 38)), image.NewPaletted(image.Rect(0, 0, 4, 4), nil)},
+	}
+
+	return newTestDCC(t, directions...), directions
+}
+
+func TestOpaqueBounds(t *testing.T) {
+	d, _ := anchorTestDCC(t)
+
+	for _, test := range []struct {
+		got, want image.Rectangle
+	}{
+		{d.Direction(0).Frame(0).OpaqueBounds(), image.Rect(14, 25, 21, 44)},
+		{d.Direction(0).OpaqueBounds(), image.Rect(12, 25, 21, 46)},
+		{d.Direction(1).Frame(1).OpaqueBounds(), image.Rectangle{}},
+		{d.Direction(1).OpaqueBounds(), image.Rect(16, 26, 20, 38)},
+		{d.OpaqueBounds(), image.Rect(12, 25, 21, 46)},
+	} {
+		if test.got != test.want {
+			t.Fatalf("expected the opaque bounds %v, got %v", test.want, test.got)
+		}
+	}
+}
+
+func TestRecenter(t *testing.T) {
+	for _, test := range []struct {
+		mode   AnchorMode
+		shift  image.Point
+		bounds image.Rectangle
+	}{
+		// the bottom row of opaque pixels is on y=0, centered on x=0
+		{AnchorFeet, image.Pt(-16, -45), image.Rect(-4, -20, 5, 1)},
+		{AnchorCenter, image.Pt(-16, -35), image.Rect(-4, -10, 5, 11)},
+	} {
+		d, before := anchorTestDCC(t)
+
+		if shift := d.Recenter(test.mode); shift != test.shift {
+			t.Fatalf("%v: expected the shift %v, got %v", test.mode, test.shift, shift)
+		}
+
+		if bounds := d.OpaqueBounds(); bounds != test.bounds {
+			t.Fatalf("%v: expected the opaque bounds %v, got %v", test.mode, test.bounds, bounds)
+		}
+
+		checkShifted(t, d, before, test.shift)
+	}
+
+	// a direction on its own is recentered on its own pixels
+	d, _ := anchorTestDCC(t)
+
+	if shift := d.Direction(1).Recenter(AnchorFeet); shift != image.Pt(-17, -37) {
+		t.Fatalf("expected the shift (-17,-37), got %v", shift)
+	}
+
+	if bounds := d.Direction(1).OpaqueBounds(); bounds != image.Rect(-1, -11, 3, 1) {
+		t.Fatalf("expected the opaque bounds (-1,-11)-(3,1), got %v", bounds)
+	}
+
+	// transparent directions are left alone
+	empty := newTestDCC(t, []*image.Paletted{image.NewPaletted(image.Rect(-3, -3, 3, 0), nil)})
+
+	if shift := empty.Recenter(AnchorFeet); shift != (image.Point{}) || empty.Direction(0).Frame(0).Box != image.Rect(-3, -3, 3, 0) {
+		t.Fatalf("expected a transparent dcc to stay where it is, got the shift %v", shift)
+	}
+}
+
+func TestShiftOffsets(t *testing.T) {
+	d, before := anchorTestDCC(t)
+
+	d.ShiftOffsets(-7, 3)
+	checkShifted(t, d, before, image.Pt(-7, 3))
+
+	d.Direction(0).ShiftOffsets(7, -3)
+	checkShifted(t, d, before[:1], image.Point{})
+	checkShifted(t, d, [][]*image.Paletted{nil, before[1]}, image.Pt(-7, 3))
+}
+
+func TestSetAnchor(t *testing.T) {
+	d, before := anchorTestDCC(t)
+	p := image.Pt(17, 30)
+	want := before[0][0].ColorIndexAt(p.X, p.Y)
+
+	d.SetAnchor(p)
+	checkShifted(t, d, before, image.Pt(-p.X, -p.Y))
+
+	if got := d.Direction(0).Frame(0).ColorIndexAt(0, 0); got != want {
+		t.Fatalf("expected the pixel at %v on the origin, got %d, expecting %d", p, got, want)
+	}
+
+	d.Direction(1).SetAnchor(image.Pt(-1, -1))
+
+	if d.Direction(1).OpaqueBounds() != image.Rect(0, -3, 4, 9) {
+		t.Fatalf("expected the direction to move on its own, got %v", d.Direction(1).OpaqueBounds())
+	}
+}
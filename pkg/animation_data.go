@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/OpenDiablo2/bitstream"
+)
+
+const (
+	animDataNumBlocks          = 256
+	animDataNumRecordsBits     = 32
+	animDataCOFNameBytes       = 8
+	animDataFramesPerDirBits   = 32
+	animDataSpeedBits          = 32
+	animDataMaxFrames          = 144
+	animDataMaxRecordsPerBlock = 1 << 16
+)
+
+// Animation timing of the game
+const (
+	// GameTicksPerSecond is the rate at which the game advances animations
+	GameTicksPerSecond = 25
+	// AnimationSpeedBase is the animation speed at which an animation advances
+	// one frame every game tick, speeds are in 1/256 frames per tick
+	AnimationSpeedBase = 256
+)
+
+// ErrAnimationDataHash is returned when a record is not in the block its COF name hashes to
+var ErrAnimationDataHash = errors.New("record is in the wrong hash block")
+
+// AnimationEvent is something that happens on a frame of an animation
+type AnimationEvent byte
+
+// Animation events
+const (
+	AnimationEventNone AnimationEvent = iota
+	AnimationEventAttack
+	AnimationEventMissile
+	AnimationEventSound
+	AnimationEventSkill
+)
+
+var animationEventNames = map[AnimationEvent]string{
+	AnimationEventNone:    "none",
+	AnimationEventAttack:  "attack",
+	AnimationEventMissile: "missile",
+	AnimationEventSound:   "sound",
+	AnimationEventSkill:   "skill",
+}
+
+func (e AnimationEvent) String() string {
+	s, ok := animationEventNames[e]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", byte(e))
+	}
+
+	return s
+}
+
+// AnimationRecord holds the timing of one animation, named like the COF of the
+// animation, for example "AMA1HTH" is the amazon attacking hand to hand
+type AnimationRecord struct {
+	COFName            string
+	FramesPerDirection int
+	// Speed is the number of 1/256 frames the animation advances every game tick
+	Speed  int
+	Events [animDataMaxFrames]AnimationEvent
+}
+
+// Event returns the event on the frame, or AnimationEventNone if there is none
+func (r *AnimationRecord) Event(frame int) AnimationEvent {
+	if frame < 0 || frame >= len(r.Events) {
+		return AnimationEventNone
+	}
+
+	return r.Events[frame]
+}
+
+// FramesPerSecond returns the number of frames the animation shows every second
+func (r *AnimationRecord) FramesPerSecond() float64 {
+	return FramesPerSecondFromSpeed(r.Speed)
+}
+
+// FrameDuration returns how long each frame of the animation is shown
+func (r *AnimationRecord) FrameDuration() time.Duration {
+	return FrameDurationFromSpeed(r.Speed)
+}
+
+// GIFDelays returns the delay of each frame in 100ths of a second, see GIFDelaysFromSpeed
+func (r *AnimationRecord) GIFDelays(frames int) []int {
+	return GIFDelaysFromSpeed(r.Speed, frames)
+}
+
+// FramesPerSecondFromSpeed returns the number of frames shown every second by
+// an animation with the given speed, in 1/256 frames per game tick
+func FramesPerSecondFromSpeed(speed int) float64 {
+	return float64(speed) * GameTicksPerSecond / AnimationSpeedBase
+}
+
+// FrameDurationFromSpeed returns how long each frame is shown by an animation
+// with the given speed. An animation with a speed of 0 does not advance, and
+// 0 is returned.
+func FrameDurationFromSpeed(speed int) time.Duration {
+	if speed <= 0 {
+		return 0
+	}
+
+	return time.Second * AnimationSpeedBase / time.Duration(speed*GameTicksPerSecond)
+}
+
+// GIFDelaysFromSpeed returns the delay of each of the frames in 100ths of a
+// second, for an animation with the given speed. The delays are rounded so
+// that the whole animation takes as long as it does in the game, even when a
+// single frame does not last a whole number of 100ths of a second.
+func GIFDelaysFromSpeed(speed, frames int) []int {
+	delays := make([]int, frames)
+	if speed <= 0 {
+		return delays
+	}
+
+	const hundredths = 100
+
+	perFrame := float64(hundredths*AnimationSpeedBase) / float64(speed*GameTicksPerSecond)
+	elapsed := 0
+
+	for idx := range delays {
+		end := int(math.Round(perFrame * float64(idx+1)))
+		delays[idx] = end - elapsed
+		elapsed = end
+	}
+
+	return delays
+}
+
+// AnimationData is the table of animation timings kept in AnimationData.d2
+type AnimationData struct {
+	records []*AnimationRecord
+	byName  map[string]*AnimationRecord
+}
+
+// NewAnimationData creates a new, empty AnimationData
+func NewAnimationData() *AnimationData {
+	return &AnimationData{byName: make(map[string]*AnimationRecord)}
+}
+
+// AnimationDataFromBytes decodes an AnimationData.d2 file from the given bytes
+func AnimationDataFromBytes(data []byte) (*AnimationData, error) {
+	return NewAnimationData().FromBytes(data)
+}
+
+// FromBytes decodes the AnimationData from the given bytes
+func (a *AnimationData) FromBytes(data []byte) (*AnimationData, error) {
+	stream := bitstream.NewReader().FromBytes(data...)
+
+	if err := a.Decode(stream); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Decode decodes the AnimationData from the given stream. The file is a hash
+// table of 256 blocks, each block holds the records whose COF name hashes to it.
+func (a *AnimationData) Decode(stream *bitstream.Reader) error {
+	decodeMutex.Lock()
+	defer decodeMutex.Unlock()
+
+	for block := 0; block < animDataNumBlocks; block++ {
+		numRecords, err := stream.Next(animDataNumRecordsBits).Bits().AsUInt32()
+		if err != nil {
+			return fmt.Errorf("block %d, %w", block, err)
+		}
+
+		if numRecords > animDataMaxRecordsPerBlock {
+			return fmt.Errorf("block %d has too many records, %d", block, numRecords)
+		}
+
+		for idx := 0; idx < int(numRecords); idx++ {
+			r, err := decodeAnimationRecord(stream)
+			if err != nil {
+				return fmt.Errorf("block %d, record %d, %w", block, idx, err)
+			}
+
+			if hash := animDataHash(r.COFName); hash != block {
+				const fmtErr = "%w: %s hashes to %d, found in %d"
+				return fmt.Errorf(fmtErr, ErrAnimationDataHash, r.COFName, hash, block)
+			}
+
+			a.add(r)
+		}
+	}
+
+	return nil
+}
+
+func decodeAnimationRecord(stream *bitstream.Reader) (*AnimationRecord, error) {
+	// like the dcc header, we only check for a stream error at the very end
+	name, _ := stream.Next(animDataCOFNameBytes).Bytes().AsBytes()
+	framesPerDirection, _ := stream.Next(animDataFramesPerDirBits).Bits().AsUInt32()
+	speed, _ := stream.Next(animDataSpeedBits).Bits().AsUInt32()
+
+	events, err := stream.Next(animDataMaxFrames).Bytes().AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// the name is null terminated
+	if idx := strings.IndexByte(string(name), 0); idx >= 0 {
+		name = name[:idx]
+	}
+
+	r := &AnimationRecord{
+		COFName:            string(name),
+		FramesPerDirection: int(framesPerDirection),
+		Speed:              int(speed),
+	}
+
+	for idx := range r.Events {
+		r.Events[idx] = AnimationEvent(events[idx])
+	}
+
+	return r, nil
+}
+
+// animDataHash returns the block of the hash table that the COF name belongs in
+func animDataHash(cofName string) int {
+	hash := 0
+
+	for _, c := range []byte(strings.ToUpper(cofName)) {
+		hash += int(c)
+	}
+
+	return hash % animDataNumBlocks
+}
+
+func (a *AnimationData) add(r *AnimationRecord) {
+	a.records = append(a.records, r)
+
+	// the game uses the first record with a name
+	key := strings.ToUpper(r.COFName)
+	if _, found := a.byName[key]; !found {
+		a.byName[key] = r
+	}
+}
+
+// Records returns every record, in the order they are stored in the file
+func (a *AnimationData) Records() []*AnimationRecord {
+	return append([]*AnimationRecord{}, a.records...)
+}
+
+// Record returns the record for the COF name, ignoring case, or nil if there is none
+func (a *AnimationData) Record(cofName string) *AnimationRecord {
+	return a.byName[strings.ToUpper(cofName)]
+}
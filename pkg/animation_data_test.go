@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeAnimationRecord writes a record of AnimationData.d2, the speed is stored
+// in the low bytes of a 32 bit field
+func writeAnimationRecord(buf *bytes.Buffer, r *AnimationRecord) {
+	name := make([]byte, animDataCOFNameBytes)
+	copy(name, r.COFName)
+	buf.Write(name)
+
+	_ = binary.Write(buf, binary.LittleEndian, uint32(r.FramesPerDirection))
+	_ = binary.Write(buf, binary.LittleEndian, uint32(r.Speed))
+
+	for _, event := range r.Events {
+		buf.WriteByte(byte(event))
+	}
+}
+
+// encodeAnimationData puts every record in the block its name hashes to,
+// unless the block is given in blocks
+func encodeAnimationData(records []*AnimationRecord, blocks map[string]int) []byte {
+	byBlock := make([][]*AnimationRecord, animDataNumBlocks)
+
+	for _, r := range records {
+		block, found := blocks[r.COFName]
+		if !found {
+			block = animDataHash(r.COFName)
+		}
+
+		byBlock[block] = append(byBlock[block], r)
+	}
+
+	buf := &bytes.Buffer{}
+
+	for _, block := range byBlock {
+		_ = binary.Write(buf, binary.LittleEndian, uint32(len(block)))
+
+		for _, r := range block {
+			writeAnimationRecord(buf, r)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func testAnimationRecords() []*AnimationRecord {
+	attack := &AnimationRecord{COFName: "AMA1HTH", FramesPerDirection: 16, Speed: 256}
+	attack.Events[7] = AnimationEventAttack
+
+	cast := &AnimationRecord{COFName: "SOSCST", FramesPerDirection: 14, Speed: 128}
+	cast.Events[3] = AnimationEventSound
+	cast.Events[9] = AnimationEventSkill
+
+	return []*AnimationRecord{
+		attack,
+		cast,
+		{COFName: "BANUHTH", FramesPerDirection: 8, Speed: 100},
+	}
+}
+
+func TestAnimationDataRoundTrip(t *testing.T) {
+	records := testAnimationRecords()
+
+	a, err := AnimationDataFromBytes(encodeAnimationData(records, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Records()) != len(records) {
+		t.Fatalf("expected %d records, got %d", len(records), len(a.Records()))
+	}
+
+	for _, want := range records {
+		got := a.Record(want.COFName)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: expected %+v, got %+v", want.COFName, want, got)
+		}
+	}
+
+	if a.Record("ama1hth") == nil {
+		t.Fatal("expected names to be case-insensitive")
+	}
+
+	if a.Record("AMA1HTH").Event(7) != AnimationEventAttack || a.Record("AMA1HTH").Event(animDataMaxFrames) != AnimationEventNone {
+		t.Fatal("unexpected events")
+	}
+}
+
+func TestAnimationDataWrongBlock(t *testing.T) {
+	records := testAnimationRecords()
+	data := encodeAnimationData(records, map[string]int{"SOSCST": animDataHash("SOSCST") + 1})
+
+	if _, err := AnimationDataFromBytes(data); !errors.Is(err, ErrAnimationDataHash) {
+		t.Fatalf("expected %v, got %v", ErrAnimationDataHash, err)
+	}
+}
+
+func TestAnimationDataTruncated(t *testing.T) {
+	data := encodeAnimationData(testAnimationRecords(), nil)
+
+	// in the middle of a record, and in the middle of the block counts after it
+	for _, size := range []int{len(data) - 1, len(data) - 100, 4 * animDataNumBlocks / 2} {
+		if _, err := AnimationDataFromBytes(data[:size]); err == nil {
+			t.Fatalf("expected an error for %d of %d bytes", size, len(data))
+		}
+	}
+}
+
+func TestAnimationTiming(t *testing.T) {
+	for _, test := range []struct {
+		speed    int
+		duration time.Duration
+		fps      float64
+		delays   []int
+	}{
+		{256, 40 * time.Millisecond, 25, []int{4, 4, 4, 4}},
+		{128, 80 * time.Millisecond, 12.5, []int{8, 8, 8, 8}},
+		// 10.24 hundredths per frame, the rounding adds up to the whole animation
+		{100, 102400 * time.Microsecond, 100.0 * 25 / 256, []int{10, 10, 11, 10}},
+		{0, 0, 0, []int{0, 0, 0, 0}},
+	} {
+		r := &AnimationRecord{Speed: test.speed}
+
+		if r.FrameDuration() != test.duration {
+			t.Fatalf("speed %d: expected a frame duration of %v, got %v", test.speed, test.duration, r.FrameDuration())
+		}
+
+		if r.FramesPerSecond() != test.fps {
+			t.Fatalf("speed %d: expected %v frames per second, got %v", test.speed, test.fps, r.FramesPerSecond())
+		}
+
+		if delays := r.GIFDelays(len(test.delays)); !reflect.DeepEqual(delays, test.delays) {
+			t.Fatalf("speed %d: expected delays %v, got %v", test.speed, test.delays, delays)
+		}
+	}
+}
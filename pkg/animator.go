@@ -0,0 +1,357 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultFrameDuration is how long an Animator shows each frame, unless told otherwise
+const DefaultFrameDuration = 100 * time.Millisecond
+
+// PlayMode is the order in which an Animator steps through the frames
+type PlayMode int
+
+// Play modes
+const (
+	// PlayForward plays from the first frame to the last
+	PlayForward PlayMode = iota
+	// PlayBackward plays from the last frame to the first
+	PlayBackward
+	// PlayPingPong plays forward to the last frame, then back to the first
+	PlayPingPong
+)
+
+var playModeNames = map[PlayMode]string{
+	PlayForward:  "forward",
+	PlayBackward: "backward",
+	PlayPingPong: "ping-pong",
+}
+
+func (m PlayMode) String() string {
+	s, ok := playModeNames[m]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// PlayModeFromString returns the play mode with the given name, as
+// yielded by PlayMode.String
+func PlayModeFromString(s string) (PlayMode, error) {
+	for mode, name := range playModeNames {
+		if strings.EqualFold(name, s) {
+			return mode, nil
+		}
+	}
+
+	return PlayForward, fmt.Errorf("unknown play mode %q", s)
+}
+
+// Animator plays the frames of a DCC. It has no clock of its own, time only
+// passes when Update is called, so the same calls always give the same frames.
+// An Animator is not safe for concurrent use.
+type Animator struct {
+	dcc           *DCC
+	direction     int
+	frame         int
+	playing       bool
+	repeat        bool
+	mode          PlayMode
+	forward       bool // the current direction of a ping-pong animation
+	frameDuration time.Duration
+	speed         float64
+	elapsed       time.Duration
+	events        [animDataMaxFrames]AnimationEvent
+	onFrame       map[int][]func(frame int)
+	onEvent       []func(frame int, event AnimationEvent)
+	onFinish      []func()
+}
+
+// NewAnimator creates an animator for the DCC, stopped on the first frame of the first direction
+func NewAnimator(d *DCC) *Animator {
+	return &Animator{
+		dcc:           d,
+		forward:       true,
+		frameDuration: DefaultFrameDuration,
+		speed:         1,
+		onFrame:       make(map[int][]func(frame int)),
+	}
+}
+
+// numFrames returns the number of frames in the current direction
+func (a *Animator) numFrames() int {
+	dir := a.dcc.Direction(a.direction)
+	if dir == nil {
+		return 0
+	}
+
+	return len(dir.frames)
+}
+
+// Play starts or resumes playing. An animation that does not repeat and has
+// ended starts over.
+func (a *Animator) Play() {
+	if !a.playing && !a.repeat && a.ended() {
+		a.Stop()
+	}
+
+	a.playing = true
+}
+
+// ended returns true if the current frame is the last one of the play mode
+func (a *Animator) ended() bool {
+	switch a.mode {
+	case PlayBackward:
+		return a.frame == 0
+	case PlayPingPong:
+		return a.frame == 0 && !a.forward
+	default:
+		return a.frame == a.numFrames()-1
+	}
+}
+
+// Pause stops playing, the current frame is kept
+func (a *Animator) Pause() {
+	a.playing = false
+}
+
+// Stop stops playing and goes back to the first frame of the play mode
+func (a *Animator) Stop() {
+	a.playing = false
+	a.elapsed = 0
+	a.forward = true
+	a.frame = 0
+
+	if a.mode == PlayBackward {
+		a.frame = a.numFrames() - 1
+	}
+
+	a.clampFrame()
+}
+
+// clampFrame keeps the current frame within the frames of the current
+// direction, a direction without frames stays on frame 0
+func (a *Animator) clampFrame() {
+	if numFrames := a.numFrames(); a.frame >= numFrames {
+		a.frame = numFrames - 1
+	}
+
+	if a.frame < 0 {
+		a.frame = 0
+	}
+}
+
+// IsPlaying returns true while the animator is playing
+func (a *Animator) IsPlaying() bool {
+	return a.playing
+}
+
+// SetRepeat sets whether the animation starts over when it ends
+func (a *Animator) SetRepeat(repeat bool) {
+	a.repeat = repeat
+}
+
+// Repeat returns true if the animation starts over when it ends
+func (a *Animator) Repeat() bool {
+	return a.repeat
+}
+
+// SetPlayMode sets the order in which the frames are played
+func (a *Animator) SetPlayMode(mode PlayMode) {
+	a.mode = mode
+	a.forward = mode != PlayBackward
+}
+
+// PlayMode returns the order in which the frames are played
+func (a *Animator) PlayMode() PlayMode {
+	return a.mode
+}
+
+// SetFrameDuration sets how long each frame is shown at normal speed. A
+// duration of 0 or less stops the animation from advancing.
+func (a *Animator) SetFrameDuration(d time.Duration) {
+	a.frameDuration = d
+}
+
+// FrameDuration returns how long each frame is shown at normal speed
+func (a *Animator) FrameDuration() time.Duration {
+	return a.frameDuration
+}
+
+// SetSpeed scales the speed of the animation, 1 is normal speed and 2 is twice
+// as fast. Negative speeds are treated as 0.
+func (a *Animator) SetSpeed(speed float64) {
+	if speed < 0 {
+		speed = 0
+	}
+
+	a.speed = speed
+}
+
+// Speed returns the speed scale of the animation
+func (a *Animator) Speed() float64 {
+	return a.speed
+}
+
+// SetAnimationRecord takes the frame duration and the frame events from the
+// AnimationData.d2 record, so the animation plays like it does in the game
+func (a *Animator) SetAnimationRecord(r *AnimationRecord) {
+	a.frameDuration = r.FrameDuration()
+	a.events = r.Events
+}
+
+// SetDirection switches to direction n. The animation carries on from the same
+// frame and time within the frame, so the direction can change mid-animation.
+func (a *Animator) SetDirection(n int) error {
+	if a.dcc.Direction(n) == nil {
+		return fmt.Errorf("%w: %d", ErrDirectionOutOfRange, n)
+	}
+
+	a.direction = n
+	a.clampFrame()
+
+	return nil
+}
+
+// Direction returns the index of the current direction
+func (a *Animator) Direction() int {
+	return a.direction
+}
+
+// SetFrame jumps to frame n of the current direction, without calling the callbacks
+func (a *Animator) SetFrame(n int) error {
+	if n < 0 || n >= a.numFrames() {
+		return fmt.Errorf("%w, %d", ErrFrameOutOfRange, n)
+	}
+
+	a.frame = n
+	a.elapsed = 0
+
+	return nil
+}
+
+// Frame returns the index of the current frame
+func (a *Animator) Frame() int {
+	return a.frame
+}
+
+// CurrentFrame returns the current frame of the current direction
+func (a *Animator) CurrentFrame() *Frame {
+	dir := a.dcc.Direction(a.direction)
+	if dir == nil {
+		return nil
+	}
+
+	return dir.Frame(a.frame)
+}
+
+// OnFrame calls fn every time the animation steps to the frame
+func (a *Animator) OnFrame(frame int, fn func(frame int)) {
+	a.onFrame[frame] = append(a.onFrame[frame], fn)
+}
+
+// OnEvent calls fn every time the animation steps to a frame with an event,
+// see SetAnimationRecord
+func (a *Animator) OnEvent(fn func(frame int, event AnimationEvent)) {
+	a.onEvent = append(a.onEvent, fn)
+}
+
+// OnFinish calls fn when an animation that does not repeat ends
+func (a *Animator) OnFinish(fn func()) {
+	a.onFinish = append(a.onFinish, fn)
+}
+
+// Update advances the animation by dt, stepping through as many frames as
+// that covers. The callbacks are called for every frame that is stepped to.
+func (a *Animator) Update(dt time.Duration) {
+	if !a.playing || a.frameDuration <= 0 {
+		return
+	}
+
+	a.elapsed += time.Duration(float64(dt) * a.speed)
+
+	for a.playing && a.elapsed >= a.frameDuration {
+		a.elapsed -= a.frameDuration
+		a.step()
+	}
+}
+
+// step moves to the next frame of the play mode
+func (a *Animator) step() {
+	numFrames := a.numFrames()
+	if numFrames < 1 {
+		a.finish()
+		return
+	}
+
+	last := numFrames - 1
+	next := a.frame
+
+	switch a.mode {
+	case PlayForward:
+		next++
+	case PlayBackward:
+		next--
+	case PlayPingPong:
+		if (a.forward && a.frame >= last) || (!a.forward && a.frame <= 0) {
+			a.forward = !a.forward
+		}
+
+		if a.forward {
+			next++
+		} else {
+			next--
+		}
+	}
+
+	switch {
+	case numFrames == 1:
+		next = 0
+	case next > last:
+		next = 0
+	case next < 0:
+		next = last
+	}
+
+	// a ping-pong animation ends when it is back on the first frame
+	wrapped := (a.mode == PlayForward && next < a.frame) ||
+		(a.mode == PlayBackward && next > a.frame) ||
+		numFrames == 1
+
+	if wrapped && !a.repeat {
+		a.finish()
+		return
+	}
+
+	a.frame = next
+	a.enter(next)
+
+	if a.mode == PlayPingPong && !a.forward && next == 0 && !a.repeat {
+		a.finish()
+	}
+}
+
+func (a *Animator) enter(frame int) {
+	for _, fn := range a.onFrame[frame] {
+		fn(frame)
+	}
+
+	if frame >= len(a.events) || a.events[frame] == AnimationEventNone {
+		return
+	}
+
+	for _, fn := range a.onEvent {
+		fn(frame, a.events[frame])
+	}
+}
+
+func (a *Animator) finish() {
+	a.playing = false
+	a.elapsed = 0
+
+	for _, fn := range a.onFinish {
+		fn()
+	}
+}
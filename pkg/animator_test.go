@@ -0,0 +1,218 @@
+package pkg
+
+import (
+	"image"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testAnimatorFrames = 4
+
+// newTestAnimator returns an animator for a DCC with two directions of four frames
+func newTestAnimator(t *testing.T) *Animator {
+	t.Helper()
+
+	directions := make([][]*image.Paletted, 2)
+
+	for dir := range directions {
+		for frame := 0; frame < testAnimatorFrames; frame++ {
+			directions[dir] = append(directions[dir], filledImage(image.Rect(-2, -2, 2, 0), uint8(1+frame)))
+		}
+	}
+
+	return NewAnimator(newTestDCC(t, directions...))
+}
+
+func TestAnimatorPlayModes(t *testing.T) {
+	for _, test := range []struct {
+		mode     PlayMode
+		repeat   bool
+		frames   []int
+		finished int
+	}{
+		{PlayForward, false, []int{1, 2, 3, 3, 3}, 1},
+		{PlayForward, true, []int{1, 2, 3, 0, 1}, 0},
+		{PlayBackward, false, []int{2, 1, 0, 0, 0}, 1},
+		{PlayBackward, true, []int{2, 1, 0, 3, 2}, 0},
+		{PlayPingPong, false, []int{1, 2, 3, 2, 1, 0, 0}, 1},
+		{PlayPingPong, true, []int{1, 2, 3, 2, 1, 0, 1, 2}, 0},
+	} {
+		a := newTestAnimator(t)
+		a.SetPlayMode(test.mode)
+		a.SetRepeat(test.repeat)
+		a.Stop()
+		a.Play()
+
+		finished := 0
+		a.OnFinish(func() { finished++ })
+
+		got := make([]int, 0, len(test.frames))
+
+		for range test.frames {
+			a.Update(DefaultFrameDuration)
+			got = append(got, a.Frame())
+		}
+
+		if !reflect.DeepEqual(got, test.frames) || finished != test.finished {
+			t.Fatalf("%v, repeat %v: expected frames %v and %d finishes, got %v and %d",
+				test.mode, test.repeat, test.frames, test.finished, got, finished)
+		}
+
+		if a.IsPlaying() == (test.finished > 0) {
+			t.Fatalf("%v, repeat %v: expected playing to be %v", test.mode, test.repeat, test.finished == 0)
+		}
+	}
+}
+
+func TestAnimatorCallbacks(t *testing.T) {
+	a := newTestAnimator(t)
+	a.SetRepeat(true)
+
+	record := &AnimationRecord{Speed: AnimationSpeedBase}
+	record.Events[2] = AnimationEventAttack
+	a.SetAnimationRecord(record)
+
+	entered := make([]int, 0)
+	a.OnFrame(2, func(frame int) { entered = append(entered, frame) })
+
+	events := make([]AnimationEvent, 0)
+	a.OnEvent(func(frame int, event AnimationEvent) {
+		if frame != 2 {
+			t.Fatalf("expected the event on frame 2, got frame %d", frame)
+		}
+
+		events = append(events, event)
+	})
+
+	a.Play()
+
+	for idx := 0; idx < 2*testAnimatorFrames; idx++ {
+		a.Update(record.FrameDuration())
+	}
+
+	if !reflect.DeepEqual(entered, []int{2, 2}) {
+		t.Fatalf("expected frame 2 to be entered twice, got %v", entered)
+	}
+
+	if !reflect.DeepEqual(events, []AnimationEvent{AnimationEventAttack, AnimationEventAttack}) {
+		t.Fatalf("expected two attack events, got %v", events)
+	}
+
+	// jumping to a frame does not call the callbacks
+	if err := a.SetFrame(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entered) != 2 || len(events) != 2 {
+		t.Fatal("expected SetFrame not to call the callbacks")
+	}
+}
+
+func TestAnimatorUpdate(t *testing.T) {
+	for _, test := range []struct {
+		speed  float64
+		repeat bool
+		dt     []time.Duration
+		frame  int
+	}{
+		{1, true, []time.Duration{DefaultFrameDuration - 1}, 0},
+		{1, true, []time.Duration{DefaultFrameDuration / 2, DefaultFrameDuration / 2}, 1},
+		{2, true, []time.Duration{DefaultFrameDuration / 2}, 1},
+		{0.5, true, []time.Duration{DefaultFrameDuration}, 0},
+		{0.5, true, []time.Duration{DefaultFrameDuration, DefaultFrameDuration}, 1},
+		{0, true, []time.Duration{time.Hour}, 0},
+		{1, true, []time.Duration{DefaultFrameDuration * 5 / 2, DefaultFrameDuration / 2}, 3},
+		{1, true, []time.Duration{DefaultFrameDuration * 13}, 1},
+		{1, false, []time.Duration{DefaultFrameDuration * 13}, 3},
+	} {
+		a := newTestAnimator(t)
+		a.SetSpeed(test.speed)
+		a.SetRepeat(test.repeat)
+		a.Play()
+
+		for _, dt := range test.dt {
+			a.Update(dt)
+		}
+
+		if a.Frame() != test.frame {
+			t.Fatalf("speed %v, repeat %v, updates %v: expected frame %d, got %d",
+				test.speed, test.repeat, test.dt, test.frame, a.Frame())
+		}
+	}
+}
+
+func TestAnimatorPlayRestarts(t *testing.T) {
+	a := newTestAnimator(t)
+	a.Play()
+	a.Update(DefaultFrameDuration * testAnimatorFrames)
+
+	if a.IsPlaying() || a.Frame() != testAnimatorFrames-1 {
+		t.Fatalf("expected the animation to end on the last frame, got frame %d", a.Frame())
+	}
+
+	a.Play()
+
+	if !a.IsPlaying() || a.Frame() != 0 {
+		t.Fatalf("expected Play to start over, got frame %d", a.Frame())
+	}
+
+	a.Update(DefaultFrameDuration)
+
+	if a.Frame() != 1 {
+		t.Fatalf("expected frame 1, got %d", a.Frame())
+	}
+
+	// a paused animation carries on where it was
+	a.Pause()
+	a.Play()
+
+	if a.Frame() != 1 {
+		t.Fatalf("expected Play to resume on frame 1, got %d", a.Frame())
+	}
+}
+
+func TestAnimatorSetDirection(t *testing.T) {
+	a := newTestAnimator(t)
+	d := a.dcc
+
+	if err := a.SetFrame(testAnimatorFrames - 1); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := d.directions[1].frames
+
+	// a direction without frames
+	d.directions[1].frames = nil
+
+	if err := a.SetDirection(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Frame() != 0 || a.CurrentFrame() != nil {
+		t.Fatalf("expected frame 0 and no current frame, got frame %d", a.Frame())
+	}
+
+	// a direction with fewer frames
+	d.directions[1].frames = frames[:2]
+
+	if err := a.SetDirection(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.SetFrame(testAnimatorFrames - 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.SetDirection(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Frame() != 1 || a.CurrentFrame() != frames[1] {
+		t.Fatalf("expected the last frame of the direction, got frame %d", a.Frame())
+	}
+
+	if err := a.SetDirection(2); err == nil {
+		t.Fatal("expected an error for a direction out of range")
+	}
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/OpenDiablo2/dcc/pkg/mpq"
+)
+
+// archiveSeparator separates the path of an archive from the path of a file inside of it
+const archiveSeparator = ".mpq:"
+
+// SplitArchivePath splits a path like "d2data.mpq:data/global/monsters/..." into the
+// path of the archive and the path of the file in the archive. ok is false if the
+// path does not point inside of an archive.
+func SplitArchivePath(path string) (archivePath, name string, ok bool) {
+	idx := strings.Index(strings.ToLower(path), archiveSeparator)
+	if idx < 0 {
+		return path, "", false
+	}
+
+	split := idx + len(archiveSeparator) - 1
+
+	return path[:split], path[split+1:], true
+}
+
+// ReadFile reads a file from disk, or from inside of an MPQ archive when the
+// path is written as "archive.mpq:path/in/archive"
+func ReadFile(path string) ([]byte, error) {
+	archivePath, name, ok := SplitArchivePath(path)
+	if !ok {
+		return ioutil.ReadFile(path)
+	}
+
+	archive, err := mpq.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = archive.Close() }()
+
+	return archive.ReadFile(name)
+}
+
+// FromFile decodes the DCC at the given path, see ReadFile for the paths that can be used
+func FromFile(path string) (*DCC, error) {
+	data, err := ReadFile(path)
+	if err != nil {
+		const fmtErr = "could not read file, %w"
+		return nil, fmt.Errorf(fmtErr, err)
+	}
+
+	return FromBytes(data)
+}
@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/bitstream"
+)
+
+const (
+	cofNumLayersBits          = 8
+	cofFramesPerDirectionBits = 8
+	cofNumDirectionsBits      = 8
+	cofVersionBits            = 8
+	cofUnknownHeaderBytes     = 20
+	cofSpeedBits              = 8
+	cofUnknownBodyBytes       = 3
+	cofLayerTypeBits          = 8
+	cofLayerShadowBits        = 8
+	cofLayerSelectableBits    = 8
+	cofLayerTransparentBits   = 8
+	cofLayerDrawEffectBits    = 8
+	cofLayerWeaponClassBytes  = 4
+	cofKeyframeBits           = 8
+	cofPriorityBits           = 8
+)
+
+// CompositeType is the type of a COF layer, for example the head or torso.
+type CompositeType int
+
+// Composite types
+const (
+	CompositeTypeHead CompositeType = iota
+	CompositeTypeTorso
+	CompositeTypeLegs
+	CompositeTypeRightArm
+	CompositeTypeLeftArm
+	CompositeTypeRightHand
+	CompositeTypeLeftHand
+	CompositeTypeShield
+	CompositeTypeSpecial1
+	CompositeTypeSpecial2
+	CompositeTypeSpecial3
+	CompositeTypeSpecial4
+	CompositeTypeSpecial5
+	CompositeTypeSpecial6
+	CompositeTypeSpecial7
+	CompositeTypeSpecial8
+	numCompositeTypes
+)
+
+var compositeTypeCodes = [numCompositeTypes]string{
+	"HD", "TR", "LG", "RA", "LA", "RH", "LH", "SH",
+	"S1", "S2", "S3", "S4", "S5", "S6", "S7", "S8",
+}
+
+// String returns the two-letter layer code, as used in file names (HD, TR, ...)
+func (c CompositeType) String() string {
+	if c < 0 || c >= numCompositeTypes {
+		return "??"
+	}
+
+	return compositeTypeCodes[c]
+}
+
+// CompositeTypeFromString returns the composite type for a two-letter layer code
+func CompositeTypeFromString(s string) (CompositeType, error) {
+	for idx, code := range compositeTypeCodes {
+		if strings.EqualFold(code, s) {
+			return CompositeType(idx), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown composite layer type %q", s)
+}
+
+// COFDrawEffect is the draw effect used for a transparent COF layer
+type COFDrawEffect byte
+
+// COF draw effects, names courtesy of Necrolis
+const (
+	COFDrawEffectTrans25 COFDrawEffect = iota
+	COFDrawEffectTrans50
+	COFDrawEffectTrans75
+	COFDrawEffectModulate
+	COFDrawEffectBurn
+	COFDrawEffectNormal
+	COFDrawEffectMod2XTrans
+	COFDrawEffectMod2X
+	COFDrawEffectNone
+)
+
+// DrawMode returns the draw mode that is closest to the draw effect
+func (e COFDrawEffect) DrawMode() DrawMode {
+	switch e {
+	case COFDrawEffectTrans25:
+		return DrawModeTrans25
+	case COFDrawEffectTrans50:
+		return DrawModeTrans50
+	case COFDrawEffectTrans75:
+		return DrawModeTrans75
+	case COFDrawEffectModulate:
+		return DrawModeAdditive
+	case COFDrawEffectBurn:
+		return DrawModeLuminance
+	default:
+		return DrawModeNormal
+	}
+}
+
+// COFLayer describes a single layer of a COF
+type COFLayer struct {
+	Type        CompositeType
+	Shadow      byte
+	Selectable  bool
+	Transparent bool
+	DrawEffect  COFDrawEffect
+	WeaponClass string
+}
+
+// DrawMode returns the draw mode the layer should be drawn with
+func (l *COFLayer) DrawMode() DrawMode {
+	if !l.Transparent {
+		return DrawModeNormal
+	}
+
+	return l.DrawEffect.DrawMode()
+}
+
+// NewCOF creates a new, empty COF
+func NewCOF() *COF {
+	return &COF{}
+}
+
+// COFFromBytes decodes a COF from the given bytes
+func COFFromBytes(data []byte) (*COF, error) {
+	return NewCOF().FromBytes(data)
+}
+
+// COF is a "component object file", it describes how the layers of a
+// composite animation (each layer being a separate DCC) are drawn together.
+type COF struct {
+	NumberOfDirections int
+	FramesPerDirection int
+	Version            byte
+	Speed              int
+	Layers             []COFLayer
+	Keyframes          []byte
+	// Priority is the draw order of the layers, indexed by direction and frame,
+	// the first layer is drawn first (at the back).
+	Priority [][][]CompositeType
+}
+
+// FromBytes decodes the COF from the given bytes
+func (c *COF) FromBytes(data []byte) (*COF, error) {
+	stream := bitstream.NewReader().FromBytes(data...)
+
+	if err := c.Decode(stream); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Decode decodes the COF from the given stream
+func (c *COF) Decode(stream *bitstream.Reader) error {
+	decodeMutex.Lock()
+	defer decodeMutex.Unlock()
+
+	if err := c.decodeHeader(stream); err != nil {
+		return fmt.Errorf("error decoding cof header, %w", err)
+	}
+
+	if err := c.decodeLayers(stream); err != nil {
+		return fmt.Errorf("error decoding cof layers, %w", err)
+	}
+
+	if err := c.decodePriority(stream); err != nil {
+		return fmt.Errorf("error decoding cof priority, %w", err)
+	}
+
+	return nil
+}
+
+func (c *COF) decodeHeader(stream *bitstream.Reader) error {
+	// like the dcc header, we only check for a stream error at the very end
+	numLayers, _ := stream.Next(cofNumLayersBits).Bits().AsByte()
+	framesPerDirection, _ := stream.Next(cofFramesPerDirectionBits).Bits().AsByte()
+	numDirections, _ := stream.Next(cofNumDirectionsBits).Bits().AsByte()
+
+	c.Layers = make([]COFLayer, numLayers)
+	c.FramesPerDirection = int(framesPerDirection)
+	c.NumberOfDirections = int(numDirections)
+
+	c.Version, _ = stream.Next(cofVersionBits).Bits().AsByte()
+
+	// unknown bytes, including the bounding box of the animation
+	_, _ = stream.Next(cofUnknownHeaderBytes).Bytes().AsBytes()
+
+	speed, _ := stream.Next(cofSpeedBits).Bits().AsByte()
+	c.Speed = int(speed)
+
+	_, err := stream.Next(cofUnknownBodyBytes).Bytes().AsBytes()
+
+	return err
+}
+
+func (c *COF) decodeLayers(stream *bitstream.Reader) (err error) {
+	for idx := range c.Layers {
+		layer := &c.Layers[idx]
+
+		layerType, _ := stream.Next(cofLayerTypeBits).Bits().AsByte()
+		layer.Type = CompositeType(layerType)
+
+		layer.Shadow, _ = stream.Next(cofLayerShadowBits).Bits().AsByte()
+
+		selectable, _ := stream.Next(cofLayerSelectableBits).Bits().AsByte()
+		layer.Selectable = selectable > 0
+
+		transparent, _ := stream.Next(cofLayerTransparentBits).Bits().AsByte()
+		layer.Transparent = transparent > 0
+
+		drawEffect, _ := stream.Next(cofLayerDrawEffectBits).Bits().AsByte()
+		layer.DrawEffect = COFDrawEffect(drawEffect)
+
+		weaponClass, err := stream.Next(cofLayerWeaponClassBytes).Bytes().AsBytes()
+		if err != nil {
+			return fmt.Errorf("layer %d, %w", idx, err)
+		}
+
+		layer.WeaponClass = strings.TrimSpace(strings.ReplaceAll(string(weaponClass), "\x00", ""))
+	}
+
+	c.Keyframes = make([]byte, c.FramesPerDirection)
+
+	for idx := range c.Keyframes {
+		if c.Keyframes[idx], err = stream.Next(cofKeyframeBits).Bits().AsByte(); err != nil {
+			return fmt.Errorf("keyframe %d, %w", idx, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *COF) decodePriority(stream *bitstream.Reader) error {
+	c.Priority = make([][][]CompositeType, c.NumberOfDirections)
+
+	for dirIdx := range c.Priority {
+		c.Priority[dirIdx] = make([][]CompositeType, c.FramesPerDirection)
+
+		for frameIdx := range c.Priority[dirIdx] {
+			c.Priority[dirIdx][frameIdx] = make([]CompositeType, len(c.Layers))
+
+			for layerIdx := range c.Priority[dirIdx][frameIdx] {
+				val, err := stream.Next(cofPriorityBits).Bits().AsByte()
+				if err != nil {
+					const fmtErr = "direction %d, frame %d, %w"
+					return fmt.Errorf(fmtErr, dirIdx, frameIdx, err)
+				}
+
+				c.Priority[dirIdx][frameIdx][layerIdx] = CompositeType(val)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Layer returns the layer of the given type, or nil if the COF has no such layer
+func (c *COF) Layer(t CompositeType) *COFLayer {
+	for idx := range c.Layers {
+		if c.Layers[idx].Type == t {
+			return &c.Layers[idx]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// Composite renders multi-layer animations, as described by a COF, where
+// each layer of the COF is a separate DCC.
+type Composite struct {
+	cof    *COF
+	layers map[CompositeType]*DCC
+}
+
+// NewComposite creates a composite for the given COF and layer DCCs.
+// Layers of the COF that have no DCC are not drawn. Every DCC must have
+// the same number of directions as the COF, and at least as many frames
+// per direction.
+func NewComposite(cof *COF, layers map[CompositeType]*DCC) (*Composite, error) {
+	for layerType, d := range layers {
+		if d == nil {
+			continue
+		}
+
+		if len(d.directions) != cof.NumberOfDirections {
+			const fmtErr = "layer %v has %d directions, the cof has %d"
+			return nil, fmt.Errorf(fmtErr, layerType, len(d.directions), cof.NumberOfDirections)
+		}
+
+		if int(d.framesPerDirection) < cof.FramesPerDirection {
+			const fmtErr = "layer %v has %d frames per direction, the cof has %d"
+			return nil, fmt.Errorf(fmtErr, layerType, d.framesPerDirection, cof.FramesPerDirection)
+		}
+	}
+
+	return &Composite{cof: cof, layers: layers}, nil
+}
+
+// COF returns the COF of the composite
+func (c *Composite) COF() *COF {
+	return c.cof
+}
+
+// Bounds returns the union of the boxes of every layer in the given direction
+func (c *Composite) Bounds(direction int) image.Rectangle {
+	r := image.Rectangle{}
+
+	for _, d := range c.layers {
+		if d == nil {
+			continue
+		}
+
+		if dir := d.Direction(direction); dir != nil {
+			r = r.Union(dir.Bounds())
+		}
+	}
+
+	return r
+}
+
+// Draw draws the layers of the given direction and frame in priority order.
+// The origin of the composite (where the anchors of all layers are) is placed
+// at the given point.
+func (c *Composite) Draw(dst draw.Image, at image.Point, direction, frame int) error {
+	if direction < 0 || direction >= c.cof.NumberOfDirections {
+		return fmt.Errorf("direction %d out of range", direction)
+	}
+
+	if frame < 0 || frame >= c.cof.FramesPerDirection {
+		return fmt.Errorf("frame %d out of range", frame)
+	}
+
+	for _, layerType := range c.cof.Priority[direction][frame] {
+		d, found := c.layers[layerType]
+		if !found || d == nil {
+			continue
+		}
+
+		layer := c.cof.Layer(layerType)
+		if layer == nil {
+			continue
+		}
+
+		d.Direction(direction).Frame(frame).Draw(dst, at, layer.DrawMode())
+	}
+
+	return nil
+}
+
+// Image renders the given direction and frame into a new image. The bounds
+// of the image are the union of the layer boxes for the direction.
+func (c *Composite) Image(direction, frame int) (*image.RGBA, error) {
+	img := image.NewRGBA(c.Bounds(direction))
+
+	if err := c.Draw(img, image.Point{}, direction, frame); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCompositeSkipsNilLayers(t *testing.T) {
+	box := image.Rect(-2, -3, 2, 1)
+	torso := newTestDCC(t, []*image.Paletted{filledImage(box, 1)})
+
+	cof := &COF{
+		NumberOfDirections: 1,
+		FramesPerDirection: 1,
+		Layers:             []COFLayer{{Type: CompositeTypeHead}, {Type: CompositeTypeTorso}},
+		Priority:           [][][]CompositeType{{{CompositeTypeHead, CompositeTypeTorso}}},
+	}
+
+	c, err := NewComposite(cof, map[CompositeType]*DCC{
+		CompositeTypeHead:  nil,
+		CompositeTypeTorso: torso,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Bounds(0); got != box {
+		t.Fatalf("expected bounds %v, got %v", box, got)
+	}
+
+	img, err := c.Image(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, a := img.At(0, 0).RGBA(); a == 0 {
+		t.Fatal("expected the torso to be drawn")
+	}
+}
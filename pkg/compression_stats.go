@@ -0,0 +1,76 @@
+package pkg
+
+// maxPixelMaskBits is the number of bits in a cell pixel mask
+const maxPixelMaskBits = 4
+
+// CompressionStats describes how the pixel data of a direction was coded.
+// The counters are gathered while decoding.
+type CompressionStats struct {
+	// Cells is the number of frame cells in the direction
+	Cells int `json:"cells"`
+	// EqualCells is the number of cells reused from the previous frame
+	EqualCells int `json:"equalCells"`
+	// PixelMaskPopCount is the number of decoded cells by the number of bits set in the pixel mask
+	PixelMaskPopCount [maxPixelMaskBits + 1]int `json:"pixelMaskPopCount"`
+	// RawCodedCells is the number of cells with pixel values read from the raw pixel codes stream
+	RawCodedCells int `json:"rawCodedCells"`
+	// DisplacementCodedCells is the number of cells with pixel values coded as displacements
+	DisplacementCodedCells int `json:"displacementCodedCells"`
+	// SolidCells is the number of cells filled with a single color
+	SolidCells int `json:"solidCells"`
+	// OneBitCells and TwoBitCells are the number of cells with 1 or 2 bits per pixel
+	OneBitCells int `json:"oneBitCells"`
+	TwoBitCells int `json:"twoBitCells"`
+	// StreamBits is the number of bits read from each of the substreams
+	StreamBits StreamBits `json:"streamBits"`
+	// PaletteEntries is the number of palette entries used by the direction
+	PaletteEntries int `json:"paletteEntries"`
+	// RawSize is the size, in bytes, of every frame stored uncompressed at the size of the direction box
+	RawSize int `json:"rawSize"`
+}
+
+// StreamBits holds the number of bits read from each substream of a direction
+type StreamBits struct {
+	EqualCells    int `json:"equalCells"`
+	PixelMask     int `json:"pixelMask"`
+	EncodingType  int `json:"encodingType"`
+	RawPixelCodes int `json:"rawPixelCodes"`
+	PixelCodes    int `json:"pixelCodesAndDisplacement"`
+}
+
+// Total returns the number of bits in all substreams
+func (s StreamBits) Total() int {
+	return s.EqualCells + s.PixelMask + s.EncodingType + s.RawPixelCodes + s.PixelCodes
+}
+
+// Share returns the fraction of the total bits that each substream takes,
+// in the same order as the fields of StreamBits
+func (s StreamBits) Share() [5]float64 {
+	total := float64(s.Total())
+	if total == 0 {
+		return [5]float64{}
+	}
+
+	return [5]float64{
+		float64(s.EqualCells) / total,
+		float64(s.PixelMask) / total,
+		float64(s.EncodingType) / total,
+		float64(s.RawPixelCodes) / total,
+		float64(s.PixelCodes) / total,
+	}
+}
+
+// CodedSize returns the size, in bytes, of the coded substreams
+func (s *CompressionStats) CodedSize() int {
+	return (s.StreamBits.Total() + bitsPerByte - 1) / bitsPerByte
+}
+
+// Ratio returns the compression ratio, the raw size divided by the coded size
+func (s *CompressionStats) Ratio() float64 {
+	coded := s.CodedSize()
+	if coded == 0 {
+		return 0
+	}
+
+	return float64(s.RawSize) / float64(coded)
+}
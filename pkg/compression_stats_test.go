@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// checkStats checks the counters that have to add up whatever the pixels are
+func checkStats(t *testing.T, name string, dir *Direction) {
+	t.Helper()
+
+	stats := dir.Stats
+
+	decoded := 0
+	for _, n := range stats.PixelMaskPopCount {
+		decoded += n
+	}
+
+	if decoded != stats.Cells-stats.EqualCells {
+		t.Fatalf("%s: expected %d decoded cells, got %d", name, stats.Cells-stats.EqualCells, decoded)
+	}
+
+	if raw := len(dir.Frames()) * dir.Box.Dx() * dir.Box.Dy(); stats.RawSize != raw {
+		t.Fatalf("%s: expected a raw size of %d, got %d", name, raw, stats.RawSize)
+	}
+
+	if stats.StreamBits.Total() == 0 || stats.Ratio() <= 0 {
+		t.Fatalf("%s: expected coded substreams, got %+v", name, stats.StreamBits)
+	}
+}
+
+func TestCompressionStatsEqualCells(t *testing.T) {
+	const numFrames = 4
+
+	img := regionImage(image.Rect(-32, -48, 16, 0), 0)
+	images := make([]*image.Paletted, numFrames)
+
+	for idx := range images {
+		images[idx] = img
+	}
+
+	decoded, _ := encodeDecode(t, newTestDCC(t, images))
+	dir := decoded.Direction(0)
+	stats := dir.Stats
+
+	checkStats(t, "repeated", dir)
+
+	// every cell after the first frame is the same as the one before it
+	perFrame := stats.Cells / numFrames
+	if stats.EqualCells != perFrame*(numFrames-1) {
+		t.Fatalf("expected %d equal cells out of %d, got %d", perFrame*(numFrames-1), stats.Cells, stats.EqualCells)
+	}
+}
+
+func TestCompressionStatsUniqueCells(t *testing.T) {
+	d, _ := regionDCC(t, 1, 3, 0)
+	decoded, _ := encodeDecode(t, d)
+	dir := decoded.Direction(0)
+	stats := dir.Stats
+
+	checkStats(t, "unique", dir)
+
+	if stats.Cells == 0 || stats.EqualCells != 0 {
+		t.Fatalf("expected no equal cells out of %d, got %d", stats.Cells, stats.EqualCells)
+	}
+}
+
+func TestStreamBits(t *testing.T) {
+	bits := StreamBits{EqualCells: 4, PixelMask: 8, EncodingType: 2, RawPixelCodes: 6, PixelCodes: 20}
+
+	if bits.Total() != 40 {
+		t.Fatalf("expected 40 bits, got %d", bits.Total())
+	}
+
+	want := [5]float64{0.1, 0.2, 0.05, 0.15, 0.5}
+	for idx, share := range bits.Share() {
+		if math.Abs(share-want[idx]) > 1e-9 {
+			t.Fatalf("expected the shares %v, got %v", want, bits.Share())
+		}
+	}
+
+	if (StreamBits{}).Share() != [5]float64{} {
+		t.Fatal("expected no shares without bits")
+	}
+
+	stats := CompressionStats{StreamBits: bits, RawSize: 50}
+	if stats.CodedSize() != 5 || stats.Ratio() != 10 {
+		t.Fatalf("expected 5 coded bytes and a ratio of 10, got %d and %v", stats.CodedSize(), stats.Ratio())
+	}
+
+	if (&CompressionStats{RawSize: 50}).Ratio() != 0 {
+		t.Fatal("expected no ratio without coded bytes")
+	}
+}
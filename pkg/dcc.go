@@ -1,9 +1,9 @@
 package pkg
 
 import (
-	"errors"
 	"fmt"
 	"image/color"
+	"sync"
 
 	"github.com/OpenDiablo2/bitstream"
 )
@@ -12,6 +12,10 @@ const (
 	fileSignature byte = 0x74
 )
 
+// decodeMutex serializes decoding. The bitstream reader reads every bit through a
+// buffer that is shared by all readers, so two streams can not be read at once.
+var decodeMutex sync.Mutex //nolint:gochecknoglobals // guards the bitstream package
+
 const (
 	sanityCheck1 int32 = 1
 )
@@ -37,10 +41,12 @@ func FromBytes(data []byte) (*DCC, error) {
 type DCC struct {
 	Version            byte
 	TotalSizeCoded     uint32
+	DirectionOffsets   []uint32 // byte offset of each direction within the file, nil after directions are changed
 	numDirections      uint32
 	framesPerDirection uint32
 	directions         []*Direction
 	palette            *color.Palette
+	trace              traceState
 	dirty              bool // when anything is changed this flag is set, causes recalculation
 }
 
@@ -78,7 +84,12 @@ func (d *DCC) Directions() []*Direction {
 	return append([]*Direction{}, d.directions...)
 }
 
+// Decode decodes the DCC from the given stream. It is safe to decode several DCCs
+// at the same time, but the decoding itself is serialized, see decodeMutex.
 func (d *DCC) Decode(stream *bitstream.Reader) error {
+	decodeMutex.Lock()
+	defer decodeMutex.Unlock()
+
 	if err := d.decodeHeader(stream); err != nil {
 		return fmt.Errorf("error decoding dcc header, %w", err)
 	}
@@ -95,30 +106,34 @@ func (d *DCC) Decode(stream *bitstream.Reader) error {
 func (d *DCC) decodeHeader(stream *bitstream.Reader) (err error) {
 	// we will only be checking the stream for a stream error at the very end.
 	// this is just to keep the line count lower and reduce the noise.
-	signature, _ := stream.Next(signatureBits).Bits().AsByte()
+	d.trace.reset()
+
+	signature, _ := d.next(stream, TraceHeaderField, StreamHeader, "Signature", signatureBits).AsByte()
 	if signature != fileSignature {
 		const fmtErr = "unexpected file signature %x, expecting %x"
 		return fmt.Errorf(fmtErr, signature, fileSignature)
 	}
 
-	d.Version, _ = stream.Next(versionBits).Bits().AsByte()
+	d.Version, _ = d.next(stream, TraceHeaderField, StreamHeader, "Version", versionBits).AsByte()
 
-	d.numDirections, _ = stream.Next(directionsBits).Bits().AsUInt32()
+	d.numDirections, _ = d.next(stream, TraceHeaderField, StreamHeader, "Directions", directionsBits).AsUInt32()
 	d.directions = make([]*Direction, d.numDirections)
 
-	d.framesPerDirection, _ = stream.Next(framesPerDirectionBits).Bits().AsUInt32()
+	d.framesPerDirection, _ = d.next(stream, TraceHeaderField, StreamHeader,
+		"FramesPerDirection", framesPerDirectionBits).AsUInt32()
 	for idx := range d.directions {
 		d.directions[idx] = &Direction{}
 		d.directions[idx].frames = make([]*Frame, d.framesPerDirection)
 	}
 
-	val, _ := stream.Next(sanityCheckBits).Bits().AsInt32()
+	val, _ := d.next(stream, TraceHeaderField, StreamHeader, "SanityCheck", sanityCheckBits).AsInt32()
 	if val != sanityCheck1 {
 		const fmtErr = "sanity check error, got %x, expecting %x"
 		return fmt.Errorf(fmtErr, val, fileSignature)
 	}
 
-	d.TotalSizeCoded, err = stream.Next(totalSizeCodedBits).Bits().AsUInt32()
+	d.TotalSizeCoded, err = d.next(stream, TraceHeaderField, StreamHeader,
+		"TotalSizeCoded", totalSizeCodedBits).AsUInt32()
 	if err != nil {
 		return err
 	}
@@ -127,9 +142,11 @@ func (d *DCC) decodeHeader(stream *bitstream.Reader) (err error) {
 }
 
 func (d *DCC) decodeBody(stream *bitstream.Reader) error {
+	d.DirectionOffsets = make([]uint32, len(d.directions))
+
 	// decode each direction
 	for idx := 0; idx < len(d.directions); idx++ {
-		offset, err := stream.Next(directionOffsetBits).Bits().AsUInt32()
+		offset, err := d.next(stream, TraceHeaderField, StreamHeader, "DirectionOffset", directionOffsetBits).AsUInt32()
 		if err != nil {
 			return err
 		}
@@ -139,7 +156,9 @@ func (d *DCC) decodeBody(stream *bitstream.Reader) error {
 			return fmt.Errorf(fmtErr, offset, stream.Length())
 		}
 
+		d.DirectionOffsets[idx] = offset
 		d.directions[idx] = &Direction{dcc: d}
+		d.trace.direction = idx
 
 		// the offset we just read is a byte offset within the file data that the direction starts at,
 		// so we want to reset the number of bits read and then set the offset here.
@@ -171,7 +190,3 @@ func (d *DCC) SetPalette(p color.Palette) {
 func (d *DCC) Palette() *color.Palette {
 	return d.palette
 }
-
-func (d *DCC) Encode() ([]byte, error) {
-	return nil, errors.New("not yet implemented")
-}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"bytes"
+	"image"
+	"sync"
+	"testing"
+)
+
+func TestDecodeConcurrently(t *testing.T) {
+	const numDecoders = 8
+
+	d := newTestDCC(t,
+		[]*image.Paletted{regionImage(image.Rect(-16, -32, 13, 0), 0), regionImage(image.Rect(-32, -16, 9, 3), 1)},
+		[]*image.Paletted{regionImage(image.Rect(0, -48, 21, -2), 2), regionImage(image.Rect(-16, -16, 16, 16), 3)},
+	)
+
+	data, err := d.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, numDecoders)
+	decoded := make([]*DCC, numDecoders)
+
+	for idx := range decoded {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			var err error
+			if decoded[idx], err = FromBytes(data); err != nil {
+				errs <- err
+			}
+		}(idx)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	for idx, got := range decoded {
+		for dir := range want.Directions() {
+			for frame, f := range want.Direction(dir).Frames() {
+				if !bytes.Equal(got.Direction(dir).Frame(frame).Paletted().Pix, f.Paletted().Pix) {
+					t.Fatalf("decoder %d, direction %d frame %d, pixels differ", idx, dir, frame)
+				}
+			}
+		}
+	}
+}
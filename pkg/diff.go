@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Diff describes the differences between two DCCs, A and B
+type Diff struct {
+	Header     []FieldDiff     `json:"header,omitempty"`
+	Directions []DirectionDiff `json:"directions,omitempty"`
+}
+
+// FieldDiff is a value that is not the same in A and B
+type FieldDiff struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+}
+
+func (f FieldDiff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", f.Field, f.A, f.B)
+}
+
+// DirectionDiff holds the differences of a direction found in both DCCs
+type DirectionDiff struct {
+	Direction int         `json:"direction"`
+	Fields    []FieldDiff `json:"fields,omitempty"`
+	Frames    []FrameDiff `json:"frames,omitempty"`
+}
+
+// FrameDiff holds the differences of a frame found in both DCCs
+type FrameDiff struct {
+	Frame  int         `json:"frame"`
+	Fields []FieldDiff `json:"fields,omitempty"`
+	Pixels PixelDiff   `json:"pixels"`
+}
+
+// PixelDiff counts the pixels that have a different palette index in A and B.
+// The pixels are compared over the union of both frame boxes.
+type PixelDiff struct {
+	Compared int `json:"compared"`
+	Changed  int `json:"changed"`
+	// Bounds is the smallest rectangle that holds every changed pixel
+	Bounds RectangleInfo `json:"bounds"`
+}
+
+// Equal returns true if no differences were found
+func (d *Diff) Equal() bool {
+	return len(d.Header) == 0 && len(d.Directions) == 0
+}
+
+type fieldDiffs []FieldDiff
+
+func (f *fieldDiffs) compare(field string, a, b interface{}) {
+	if a != b {
+		*f = append(*f, FieldDiff{Field: field, A: a, B: b})
+	}
+}
+
+// Compare returns the differences between a and b. Directions and frames are
+// only compared when both DCCs have them, a mismatch in their number is reported
+// as a header difference.
+func Compare(a, b *DCC) *Diff {
+	diff := &Diff{}
+	infoA, infoB := a.Info(), b.Info()
+
+	header := fieldDiffs{}
+	header.compare("Version", infoA.Version, infoB.Version)
+	header.compare("TotalSizeCoded", infoA.TotalSizeCoded, infoB.TotalSizeCoded)
+	header.compare("NumberOfDirections", infoA.NumberOfDirections, infoB.NumberOfDirections)
+	header.compare("FramesPerDirection", infoA.FramesPerDirection, infoB.FramesPerDirection)
+
+	for idx := 0; idx < len(infoA.DirectionOffsets) && idx < len(infoB.DirectionOffsets); idx++ {
+		header.compare(fmt.Sprintf("DirectionOffsets[%d]", idx), infoA.DirectionOffsets[idx], infoB.DirectionOffsets[idx])
+	}
+
+	diff.Header = header
+
+	for idx := 0; idx < len(a.directions) && idx < len(b.directions); idx++ {
+		dirDiff := compareDirections(a.directions[idx], b.directions[idx], &infoA.Directions[idx], &infoB.Directions[idx])
+		dirDiff.Direction = idx
+
+		if len(dirDiff.Fields) > 0 || len(dirDiff.Frames) > 0 {
+			diff.Directions = append(diff.Directions, dirDiff)
+		}
+	}
+
+	return diff
+}
+
+func compareDirections(a, b *Direction, infoA, infoB *DirectionInfo) DirectionDiff {
+	fields := fieldDiffs{}
+	fields.compare("Box", infoA.Box, infoB.Box)
+	fields.compare("CompressionFlags", infoA.CompressionFlags, infoB.CompressionFlags)
+	fields.compare("OutSizeCoded", infoA.OutSizeCoded, infoB.OutSizeCoded)
+	fields.compare("BitWidths", infoA.BitWidths, infoB.BitWidths)
+	fields.compare("Substreams", infoA.Substreams, infoB.Substreams)
+	fields.compare("Frames", len(a.frames), len(b.frames))
+
+	diff := DirectionDiff{Fields: fields}
+
+	for idx := 0; idx < len(a.frames) && idx < len(b.frames); idx++ {
+		frameDiff := compareFrames(a.frames[idx], b.frames[idx])
+		frameDiff.Frame = idx
+
+		if len(frameDiff.Fields) > 0 || frameDiff.Pixels.Changed > 0 {
+			diff.Frames = append(diff.Frames, frameDiff)
+		}
+	}
+
+	return diff
+}
+
+func compareFrames(a, b *Frame) FrameDiff {
+	fields := fieldDiffs{}
+	fields.compare("Width", a.Width, b.Width)
+	fields.compare("Height", a.Height, b.Height)
+	fields.compare("XOffset", a.XOffset, b.XOffset)
+	fields.compare("YOffset", a.YOffset, b.YOffset)
+	fields.compare("Box", rectangleInfo(a.Box), rectangleInfo(b.Box))
+	fields.compare("NumberOfOptionalBytes", a.NumberOfOptionalBytes, b.NumberOfOptionalBytes)
+	fields.compare("NumberOfCodedBytes", a.NumberOfCodedBytes, b.NumberOfCodedBytes)
+
+	area := a.Box.Union(b.Box)
+	changed := image.Rectangle{}
+	pixels := PixelDiff{Compared: area.Dx() * area.Dy()}
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if a.ColorIndexAt(x, y) == b.ColorIndexAt(x, y) {
+				continue
+			}
+
+			pixels.Changed++
+			changed = changed.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	pixels.Bounds = rectangleInfo(changed)
+
+	return FrameDiff{Fields: fields, Pixels: pixels}
+}
+
+//nolint:gochecknoglobals // colors used by DiffImage
+var (
+	diffAddedColor   = color.RGBA{G: 0xff, A: 0xff}
+	diffRemovedColor = color.RGBA{R: 0xff, A: 0xff}
+	diffChangedColor = color.RGBA{R: 0xff, G: 0xff, A: 0xff}
+)
+
+// DiffImage draws frame b with the pixels that differ from frame a highlighted.
+// Unchanged pixels are drawn faded in greyscale, pixels only in b are green,
+// pixels only in a are red and pixels with a different palette index are yellow.
+// The image covers the union of both frame boxes.
+func DiffImage(a, b *Frame) *image.RGBA {
+	const fade = 3
+
+	area := a.Box.Union(b.Box)
+	img := image.NewRGBA(area)
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			idxA, idxB := a.ColorIndexAt(x, y), b.ColorIndexAt(x, y)
+
+			switch {
+			case idxA == idxB && idxB == 0:
+				continue
+			case idxA == idxB:
+				grey := color.GrayModel.Convert(b.At(x, y)).(color.Gray)
+				grey.Y /= fade
+				img.Set(x, y, grey)
+			case idxA == 0:
+				img.SetRGBA(x, y, diffAddedColor)
+			case idxB == 0:
+				img.SetRGBA(x, y, diffRemovedColor)
+			default:
+				img.SetRGBA(x, y, diffChangedColor)
+			}
+		}
+	}
+
+	return img
+}
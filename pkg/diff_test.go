@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"image"
+	"testing"
+)
+
+func fieldNames(fields []FieldDiff) map[string]bool {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f.Field] = true
+	}
+
+	return names
+}
+
+func TestCompareEqual(t *testing.T) {
+	d, _ := regionDCC(t, 2, 3, 0)
+	_, data := encodeDecode(t, d)
+
+	a, err := FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := Compare(a, b); !diff.Equal() {
+		t.Fatalf("expected no differences, got %+v", diff)
+	}
+}
+
+func TestComparePixel(t *testing.T) {
+	const dir, frame = 1, 1
+
+	d, images := regionDCC(t, 2, 2, 0)
+	a, _ := encodeDecode(t, d)
+
+	changed := images[dir][frame]
+	at := changed.Rect.Min.Add(image.Pt(5, 6))
+	changed.SetColorIndex(at.X, at.Y, changed.ColorIndexAt(at.X, at.Y)%254+1)
+
+	b, _ := encodeDecode(t, newTestDCC(t, images...))
+
+	diff := Compare(a, b)
+	if len(diff.Directions) != 1 || diff.Directions[0].Direction != dir {
+		t.Fatalf("expected only direction %d to differ, got %+v", dir, diff.Directions)
+	}
+
+	frames := diff.Directions[0].Frames
+	if len(frames) != 1 || frames[0].Frame != frame {
+		t.Fatalf("expected only frame %d to differ, got %+v", frame, frames)
+	}
+
+	pixels := frames[0].Pixels
+	if pixels.Changed != 1 || pixels.Bounds != rectangleInfo(image.Rect(at.X, at.Y, at.X+1, at.Y+1)) {
+		t.Fatalf("expected one changed pixel at %v, got %+v", at, pixels)
+	}
+
+	if pixels.Compared != changed.Rect.Dx()*changed.Rect.Dy() {
+		t.Fatalf("expected %d pixels to be compared, got %d", changed.Rect.Dx()*changed.Rect.Dy(), pixels.Compared)
+	}
+
+	img := DiffImage(a.Direction(dir).Frame(frame), b.Direction(dir).Frame(frame))
+	if img.RGBAAt(at.X, at.Y) != diffChangedColor {
+		t.Fatalf("expected the changed pixel at %v to be highlighted, got %v", at, img.RGBAAt(at.X, at.Y))
+	}
+}
+
+func TestCompareMismatch(t *testing.T) {
+	d, _ := regionDCC(t, 2, 2, 0)
+	a, _ := encodeDecode(t, d)
+
+	d, _ = regionDCC(t, 1, 3, 0)
+	b, _ := encodeDecode(t, d)
+
+	diff := Compare(a, b)
+	header := fieldNames(diff.Header)
+
+	for _, field := range []string{"NumberOfDirections", "FramesPerDirection"} {
+		if !header[field] {
+			t.Fatalf("expected %s to differ, got %v", field, diff.Header)
+		}
+	}
+
+	if len(diff.Directions) != 1 || !fieldNames(diff.Directions[0].Fields)["Frames"] {
+		t.Fatalf("expected the number of frames of direction 0 to differ, got %+v", diff.Directions)
+	}
+}
+
+func TestDiffImage(t *testing.T) {
+	d := newTestDCC(t,
+		[]*image.Paletted{filledImage(image.Rect(0, -2, 2, 0), 5)},
+		[]*image.Paletted{filledImage(image.Rect(1, -2, 3, 0), 5)},
+	)
+
+	img := DiffImage(d.Direction(0).Frame(0), d.Direction(1).Frame(0))
+	if img.Rect != image.Rect(0, -2, 3, 0) {
+		t.Fatalf("expected the union of both boxes, got %v", img.Rect)
+	}
+
+	if img.RGBAAt(0, -1) != diffRemovedColor || img.RGBAAt(2, -1) != diffAddedColor {
+		t.Fatalf("expected removed and added pixels, got %v and %v", img.RGBAAt(0, -1), img.RGBAAt(2, -1))
+	}
+
+	if c := img.RGBAAt(1, -1); c.R != c.G || c.G != c.B || c.A != 0xff {
+		t.Fatalf("expected an unchanged pixel in grey, got %v", c)
+	}
+}
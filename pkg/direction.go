@@ -46,12 +46,14 @@ type Direction struct {
 	RawPixelCodesBitstreamSize uint32
 	frames                     []*Frame
 	PaletteEntries             [256]byte
+	PaletteEntryCount          int // the number of used entries in PaletteEntries
 	Box                        *image.Rectangle
 	Cells                      []*Cell
 	PixelData                  []byte
 	HorizontalCellCount        int
 	VerticalCellCount          int
 	PixelBuffer                PixelBuffer
+	Stats                      CompressionStats
 }
 
 func (d *Direction) decode(stream *bitstream.Reader) (err error) {
@@ -85,19 +87,23 @@ func (d *Direction) decodeHeader(stream *bitstream.Reader) (err error) {
 
 	var val uint32
 
-	val, _ = stream.Next(outSizeCodedBits).Bits().AsUInt32()
+	header := func(field string, n int) bitstream.Response {
+		return d.dcc.next(stream, TraceHeaderField, StreamHeader, field, n)
+	}
+
+	val, _ = header("OutSizeCoded", outSizeCodedBits).AsUInt32()
 	d.OutSizeCoded = int(val)
 
-	val, _ = stream.Next(compressionFlagsBits).Bits().AsUInt32()
+	val, _ = header("CompressionFlags", compressionFlagsBits).AsUInt32()
 	d.CompressionFlags = int(val)
 
-	d.Variable0Bits, _ = crazyLookup(stream.Next(variable0Bits).Bits().AsUInt32())
-	d.WidthBits, _ = crazyLookup(stream.Next(widthBits).Bits().AsUInt32())
-	d.HeightBits, _ = crazyLookup(stream.Next(heightBits).Bits().AsUInt32())
-	d.XOffsetBits, _ = crazyLookup(stream.Next(xOffsetBits).Bits().AsUInt32())
-	d.YOffsetBits, _ = crazyLookup(stream.Next(yOffsetBits).Bits().AsUInt32())
-	d.OptionalDataBits, _ = crazyLookup(stream.Next(optionalDataBits).Bits().AsUInt32())
-	d.CodedBytesBits, err = crazyLookup(stream.Next(codedBytesBits).Bits().AsUInt32())
+	d.Variable0Bits, _ = crazyLookup(header("Variable0Bits", variable0Bits).AsUInt32())
+	d.WidthBits, _ = crazyLookup(header("WidthBits", widthBits).AsUInt32())
+	d.HeightBits, _ = crazyLookup(header("HeightBits", heightBits).AsUInt32())
+	d.XOffsetBits, _ = crazyLookup(header("XOffsetBits", xOffsetBits).AsUInt32())
+	d.YOffsetBits, _ = crazyLookup(header("YOffsetBits", yOffsetBits).AsUInt32())
+	d.OptionalDataBits, _ = crazyLookup(header("OptionalDataBits", optionalDataBits).AsUInt32())
+	d.CodedBytesBits, err = crazyLookup(header("CodedBytesBits", codedBytesBits).AsUInt32())
 
 	return err
 }
@@ -134,6 +140,11 @@ func (d *Direction) decodeBody(stream *bitstream.Reader) (err error) {
 		return err
 	}
 
+	d.Stats = CompressionStats{
+		PaletteEntries: d.PaletteEntryCount,
+		RawSize:        d.Box.Dx() * d.Box.Dy() * len(d.frames),
+	}
+
 	// Fill in the pixel buffer
 	if err = d.fillPixelBuffer(pcd, ec, pm, et, rpc); err != nil {
 		const fmtErr = "filling pixel buffer, %v"
@@ -153,6 +164,14 @@ func (d *Direction) decodeBody(stream *bitstream.Reader) (err error) {
 		return err
 	}
 
+	d.Stats.StreamBits = StreamBits{
+		EqualCells:    ec.BitsRead(),
+		PixelMask:     pm.BitsRead(),
+		EncodingType:  et.BitsRead(),
+		RawPixelCodes: rpc.BitsRead(),
+		PixelCodes:    pcd.BitsRead(),
+	}
+
 	stream.OffsetBitPosition(pcd.BitsRead())
 
 	return nil
@@ -165,6 +184,7 @@ func (d *Direction) decodeFrameHeaders(stream *bitstream.Reader) error {
 	maxY := baseMaxy
 
 	for frameIdx := uint32(0); frameIdx < d.dcc.framesPerDirection; frameIdx++ {
+		d.dcc.trace.frame = int(frameIdx)
 		d.frames[frameIdx] = &Frame{
 			direction: d,
 		}
@@ -182,6 +202,8 @@ func (d *Direction) decodeFrameHeaders(stream *bitstream.Reader) error {
 		maxY = int(maxInt32(int32(bounds.Max.Y), int32(maxY)))
 	}
 
+	d.dcc.trace.frame = none
+
 	d.Box = &image.Rectangle{
 		image.Point{minX, minY},
 		image.Point{maxX, maxY},
@@ -192,15 +214,19 @@ func (d *Direction) decodeFrameHeaders(stream *bitstream.Reader) error {
 
 func (d *Direction) decodeCompressionFlags(stream *bitstream.Reader) (err error) {
 	// to reduce noise, we only return the last stream error, otherwise throw them away
+	header := func(field string) bitstream.Response {
+		return d.dcc.next(stream, TraceHeaderField, StreamHeader, field, streamSizeBits)
+	}
+
 	if (d.CompressionFlags & equalCellsCompression) > 0 {
-		d.EqualCellsBitstreamSize, _ = stream.Next(streamSizeBits).Bits().AsUInt32()
+		d.EqualCellsBitstreamSize, _ = header("EqualCellsBitstreamSize").AsUInt32()
 	}
 
-	d.PixelMaskBitstreamSize, _ = stream.Next(streamSizeBits).Bits().AsUInt32()
+	d.PixelMaskBitstreamSize, _ = header("PixelMaskBitstreamSize").AsUInt32()
 
 	if (d.CompressionFlags & rawPixelCompression) > 0 {
-		d.EncodingTypeBitstreamSize, _ = stream.Next(streamSizeBits).Bits().AsUInt32()
-		d.RawPixelCodesBitstreamSize, err = stream.Next(streamSizeBits).Bits().AsUInt32()
+		d.EncodingTypeBitstreamSize, _ = header("EncodingTypeBitstreamSize").AsUInt32()
+		d.RawPixelCodesBitstreamSize, err = header("RawPixelCodesBitstreamSize").AsUInt32()
 
 		if err != nil {
 			return fmt.Errorf("stream error, %w", err)
@@ -210,16 +236,29 @@ func (d *Direction) decodeCompressionFlags(stream *bitstream.Reader) (err error)
 	return nil
 }
 
+// trace field names for the palette entry bits, so they aren't formatted for every decode
+var paletteEntryFields = func() (names [256]string) {
+	for idx := range names {
+		names[idx] = fmt.Sprintf("PaletteEntry[%d]", idx)
+	}
+
+	return names
+}()
+
 func (d *Direction) decodePaletteEntries(stream *bitstream.Reader) (err error) {
-	for paletteEntryCount, idx := 0, 0; idx < 256; idx++ {
-		if valid, err := stream.Next(1).Bits().AsBool(); err != nil {
+	d.PaletteEntryCount = 0
+
+	for idx := 0; idx < 256; idx++ {
+		field := paletteEntryFields[idx]
+
+		if valid, err := d.dcc.next(stream, TraceHeaderField, StreamHeader, field, 1).AsBool(); err != nil {
 			return err
 		} else if !valid {
 			continue
 		}
 
-		d.PaletteEntries[paletteEntryCount] = byte(idx)
-		paletteEntryCount++
+		d.PaletteEntries[d.PaletteEntryCount] = byte(idx)
+		d.PaletteEntryCount++
 	}
 
 	return nil
@@ -323,6 +362,7 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 
 	for _, frame := range d.frames {
 		frameIndex++
+		d.dcc.trace.frame = frameIndex
 
 		originCellX := (frame.Box.Min.X - d.Box.Min.X) / cellSize
 		originCellY := (frame.Box.Min.Y - d.Box.Min.Y) / cellSize
@@ -332,12 +372,14 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 
 			for cellX := 0; cellX < frame.HorizontalCellCount; cellX++ {
 				currentCell := originCellX + cellX + (currentCellY * d.HorizontalCellCount)
+				d.dcc.trace.cell = cellX + (cellY * frame.HorizontalCellCount)
+				d.Stats.Cells++
 				nextCell := false
 				tmp := 0
 
 				if cellBuffer[currentCell] != nil {
 					if d.EqualCellsBitstreamSize > 0 {
-						val, err := ec.Next(1).Bits().AsUInt32()
+						val, err := d.dcc.next(ec, TraceEqualCell, StreamEqualCells, "EqualCell", 1).AsUInt32()
 						if err != nil {
 							const fmtErr = "reading EqualCells bitstream into cell buffer, cell index %v"
 							return fmt.Errorf(fmtErr, currentCell)
@@ -349,7 +391,7 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 					}
 
 					if tmp == 0 {
-						pixelMask, err = pm.Next(4).Bits().AsUInt32() //nolint:gomnd // binary data
+						pixelMask, err = d.dcc.next(pm, TracePixelMask, StreamPixelMask, "PixelMask", 4).AsUInt32() //nolint:gomnd // binary data
 						if err != nil {
 							const fmtErr = "reading pixel mask into cell buffer, cell index %v"
 							return fmt.Errorf(fmtErr, currentCell)
@@ -362,6 +404,7 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 				}
 
 				if nextCell {
+					d.Stats.EqualCells++
 					continue
 				}
 
@@ -373,7 +416,7 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 				encodingType := 0
 
 				if (numberOfPixelBits != 0) && (d.EncodingTypeBitstreamSize > 0) {
-					val, err := et.Next(1).Bits().AsUInt32()
+					val, err := d.dcc.next(et, TraceEncodingType, StreamEncodingType, "EncodingType", 1).AsUInt32()
 					if err != nil {
 						const fmtErr = "reading encoding type, cell index %v"
 						return fmt.Errorf(fmtErr, currentCell)
@@ -384,17 +427,25 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 					encodingType = 0
 				}
 
+				d.Stats.PixelMaskPopCount[numberOfPixelBits]++
+
+				if numberOfPixelBits != 0 && encodingType != 0 {
+					d.Stats.RawCodedCells++
+				} else if numberOfPixelBits != 0 {
+					d.Stats.DisplacementCodedCells++
+				}
+
 				decodedPixel := 0
 
 				for i := 0; i < numberOfPixelBits; i++ {
 					if encodingType != 0 {
-						if pixelStack[i], err = rp.Next(8).Bits().AsUInt32(); err != nil {
+						if pixelStack[i], err = d.dcc.next(rp, TracePixelStack, StreamRawPixelCodes, "RawPixel", 8).AsUInt32(); err != nil {
 							const fmtErr = "reading into pixel stack, cell index %v"
 							return fmt.Errorf(fmtErr, currentCell)
 						}
 					} else {
 						pixelStack[i] = lastPixel
-						pixelDisplacement, err := pcd.Next(4).Bits().AsUInt32()
+						pixelDisplacement, err := d.dcc.next(pcd, TracePixelStack, StreamPixelCodes, "PixelDisplacement", 4).AsUInt32()
 						if err != nil {
 							const fmtErr = "reading pixel displacement, cell index %v"
 							return fmt.Errorf(fmtErr, currentCell)
@@ -402,7 +453,7 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 
 						pixelStack[i] += pixelDisplacement
 						for pixelDisplacement == 15 {
-							pixelDisplacement, err = pcd.Next(4).Bits().AsUInt32()
+							pixelDisplacement, err = d.dcc.next(pcd, TracePixelStack, StreamPixelCodes, "PixelDisplacement", 4).AsUInt32()
 							if err != nil {
 								const fmtErr = "reading pixel displacement, cell index %v"
 								return fmt.Errorf(fmtErr, currentCell)
@@ -447,6 +498,8 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 		}
 	}
 
+	d.dcc.trace.frame, d.dcc.trace.cell = none, none
+
 	// Convert the palette entry index into actual palette entries
 	for i := 0; i <= pbIndex; i++ {
 		for x := 0; x < 4; x++ {
@@ -458,6 +511,8 @@ func (d *Direction) fillPixelBuffer(pcd, ec, pm, et, rp *bitstream.Reader) (err
 }
 
 func (d *Direction) generateFrames(pcd *bitstream.Reader) (err error) {
+	pbIdx := 0
+
 	for _, cell := range d.Cells {
 		cell.LastWidth = -1
 		cell.LastHeight = -1
@@ -466,12 +521,14 @@ func (d *Direction) generateFrames(pcd *bitstream.Reader) (err error) {
 	d.PixelData = make([]byte, d.Box.Dx()*d.Box.Dy())
 
 	for idx := range d.frames {
-		if err = d.generateFrame(idx, pcd); err != nil {
+		if err = d.generateFrame(idx, &pbIdx, pcd); err != nil {
 			const fmtErr = "generating frame with index %v, %v"
 			return fmt.Errorf(fmtErr, idx, err)
 		}
 	}
 
+	d.dcc.trace.frame, d.dcc.trace.cell = none, none
+
 	d.Cells = nil
 	d.PixelData = nil
 	d.PixelBuffer = nil
@@ -479,19 +536,19 @@ func (d *Direction) generateFrames(pcd *bitstream.Reader) (err error) {
 	return nil
 }
 
-func (d *Direction) generateFrame(idx int, pcd *bitstream.Reader) error {
-	pbIdx := 0
-
+func (d *Direction) generateFrame(idx int, pbIdx *int, pcd *bitstream.Reader) error {
 	d.frames[idx].PixelData = make([]byte, d.Box.Dx()*d.Box.Dy())
+	d.dcc.trace.frame = idx
 
 	for cellIdx := range d.frames[idx].Cells {
+		d.dcc.trace.cell = cellIdx
 		cell := &d.frames[idx].Cells[cellIdx]
 
 		cellX := cell.XOffset / cellSize
 		cellY := cell.YOffset / cellSize
 		cellIndex := cellX + (cellY * d.HorizontalCellCount)
 		bufferCell := d.Cells[cellIndex]
-		pbe := d.PixelBuffer[pbIdx]
+		pbe := d.PixelBuffer[*pbIdx]
 
 		if (pbe.Frame != idx) || (pbe.FrameCellIndex != cellIdx) {
 			// This buffer cell has an EqualCell bit set to 1, so copy the frame cell or clear it
@@ -524,6 +581,8 @@ func (d *Direction) generateFrame(idx int, pcd *bitstream.Reader) error {
 			}
 		} else {
 			if pbe.Value[0] == pbe.Value[1] {
+				d.Stats.SolidCells++
+
 				// Clear the frame
 				for y := 0; y < cell.Height; y++ {
 					for x := 0; x < cell.Width; x++ {
@@ -536,12 +595,18 @@ func (d *Direction) generateFrame(idx int, pcd *bitstream.Reader) error {
 				if pbe.Value[1] != pbe.Value[2] {
 					bitsToRead = 2
 				}
+
+				if bitsToRead == 1 {
+					d.Stats.OneBitCells++
+				} else {
+					d.Stats.TwoBitCells++
+				}
 				for y := 0; y < cell.Height; y++ {
 					for x := 0; x < cell.Width; x++ {
-						paletteIndex, err := pcd.Next(bitsToRead).Bits().AsUInt32()
+						paletteIndex, err := d.dcc.next(pcd, TracePixelCode, StreamPixelCodes, "PixelCode", bitsToRead).AsUInt32()
 						if err != nil {
 							const fmtErr = "reading palette index at coord(%v, %v)"
-							return fmt.Errorf(fmtErr, idx, x, y)
+							return fmt.Errorf(fmtErr, x, y)
 						}
 
 						d.PixelData[x+cell.XOffset+((y+cell.YOffset)*d.Box.Dx())] = pbe.Value[paletteIndex]
@@ -556,7 +621,7 @@ func (d *Direction) generateFrame(idx int, pcd *bitstream.Reader) error {
 					d.frames[idx].PixelData[fx+cell.XOffset+((fy+cell.YOffset)*d.Box.Dx())] = d.PixelData[fx+cell.XOffset+((fy+cell.YOffset)*d.Box.Dx())]
 				}
 			}
-			pbIdx++
+			*pbIdx++
 		}
 
 		bufferCell.LastWidth = cell.Width
@@ -626,12 +691,13 @@ func crazyLookup(idx uint32, err error) (int, error) {
 		return 0, err
 	}
 
-	// nolint:gomnd // constant
-	var crazyBitTable = []byte{0, 1, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 26, 28, 30, 32}
-
 	return int(crazyBitTable[idx]), err
 }
 
+// crazyBitTable maps the 4 bit codes in the direction header to bit widths
+// nolint:gochecknoglobals,gomnd // constant
+var crazyBitTable = [...]byte{0, 1, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 26, 28, 30, 32}
+
 func minInt32(a, b int32) int32 {
 	if a < b {
 		return a
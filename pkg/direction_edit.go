@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned when editing directions
+var (
+	ErrFrameCountMismatch = errors.New("direction has a different number of frames")
+	ErrBadDirectionOrder  = errors.New("direction order is not a permutation of the directions")
+	ErrLastDirection      = errors.New("cannot remove the only direction")
+)
+
+// clone returns a copy of the direction that belongs to the given DCC
+func (d *Direction) clone(owner *DCC) *Direction {
+	c := &Direction{
+		dcc:               owner,
+		CompressionFlags:  d.CompressionFlags,
+		PaletteEntries:    d.PaletteEntries,
+		PaletteEntryCount: d.PaletteEntryCount,
+		frames:            make([]*Frame, len(d.frames)),
+	}
+
+	for idx, f := range d.frames {
+		c.frames[idx] = f.clone()
+	}
+
+	c.edit(func() {})
+
+	return c
+}
+
+// directionsChanged updates the header after the directions were changed. The
+// direction offsets are only known once the DCC is encoded again.
+func (d *DCC) directionsChanged() {
+	d.numDirections = uint32(len(d.directions))
+	d.DirectionOffsets = nil
+	d.dirty = true
+}
+
+// AddDirection appends a copy of the direction, see InsertDirection
+func (d *DCC) AddDirection(src *Direction) error {
+	return d.InsertDirection(len(d.directions), src)
+}
+
+// InsertDirection inserts a copy of the direction before direction n, or after the
+// last direction if n is the number of directions. The direction can come from any
+// DCC, but it must have the same number of frames as the other directions.
+func (d *DCC) InsertDirection(n int, src *Direction) error {
+	if n < 0 || n > len(d.directions) {
+		return fmt.Errorf("%w: %d", ErrDirectionOutOfRange, n)
+	}
+
+	if len(d.directions) > 0 && len(src.frames) != int(d.framesPerDirection) {
+		const fmtErr = "%w: has %d, expecting %d"
+		return fmt.Errorf(fmtErr, ErrFrameCountMismatch, len(src.frames), d.framesPerDirection)
+	}
+
+	c := src.clone(d)
+
+	d.directions = append(d.directions[:n], append([]*Direction{c}, d.directions[n:]...)...)
+	d.framesPerDirection = uint32(len(c.frames))
+	d.directionsChanged()
+
+	return nil
+}
+
+// RemoveDirection removes direction n
+func (d *DCC) RemoveDirection(n int) error {
+	if n < 0 || n >= len(d.directions) {
+		return fmt.Errorf("%w: %d", ErrDirectionOutOfRange, n)
+	}
+
+	if len(d.directions) == 1 {
+		return ErrLastDirection
+	}
+
+	d.directions = append(d.directions[:n], d.directions[n+1:]...)
+	d.directionsChanged()
+
+	return nil
+}
+
+// ReorderDirections rearranges the directions, direction i becomes the
+// direction that was at order[i]
+func (d *DCC) ReorderDirections(order []int) error {
+	if len(order) != len(d.directions) {
+		return ErrBadDirectionOrder
+	}
+
+	seen := make([]bool, len(order))
+	reordered := make([]*Direction, len(order))
+
+	for idx, from := range order {
+		if from < 0 || from >= len(order) || seen[from] {
+			return ErrBadDirectionOrder
+		}
+
+		seen[from] = true
+		reordered[idx] = d.directions[from]
+	}
+
+	d.directions = reordered
+	d.directionsChanged()
+
+	return nil
+}
+
+// ResampleDirections changes the number of directions. Each new direction is a
+// copy of the existing direction facing closest to its angle, so going from 8 to
+// 16 directions repeats every direction, and going from 32 to 8 keeps the
+// directions that match. Both counts must be supported by the direction lookup tables.
+func (d *DCC) ResampleDirections(numDirections int) error {
+	if _, err := dirLookupTable(numDirections); err != nil {
+		return err
+	}
+
+	if numDirections == len(d.directions) {
+		return nil
+	}
+
+	resampled := make([]*Direction, numDirections)
+
+	for idx := range resampled {
+		dir64, err := DccToDir64(idx, numDirections)
+		if err != nil {
+			return err
+		}
+
+		from, err := DirectionFromDir64(dir64, len(d.directions))
+		if err != nil {
+			return err
+		}
+
+		resampled[idx] = d.directions[from].clone(d)
+	}
+
+	d.directions = resampled
+	d.directionsChanged()
+
+	return nil
+}
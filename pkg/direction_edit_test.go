@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+// colorDCC returns a DCC where every frame of direction n is filled with color n+1
+func colorDCC(t *testing.T, numDirections, numFrames int) *DCC {
+	t.Helper()
+
+	directions := make([][]*image.Paletted, numDirections)
+
+	for dir := range directions {
+		for frame := 0; frame < numFrames; frame++ {
+			directions[dir] = append(directions[dir], filledImage(image.Rect(-2, -4, 2, 0), uint8(dir+1)))
+		}
+	}
+
+	return newTestDCC(t, directions...)
+}
+
+// directionColor returns the color that the first pixel of the direction has
+func directionColor(d *DCC, n int) int {
+	f := d.Direction(n).Frame(0)
+	return int(f.ColorIndexAt(f.Box.Min.X, f.Box.Min.Y)) - 1
+}
+
+func TestResampleDirections(t *testing.T) {
+	for _, test := range []struct{ from, to int }{{8, 16}, {32, 8}, {4, 8}, {16, 4}} {
+		d := colorDCC(t, test.from, 2)
+
+		if err := d.ResampleDirections(test.to); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(d.Directions()) != test.to || d.numDirections != uint32(test.to) {
+			t.Fatalf("%d to %d: got %d directions", test.from, test.to, len(d.Directions()))
+		}
+
+		copies := make(map[int]int)
+
+		for dir := 0; dir < test.to; dir++ {
+			dir64, err := DccToDir64(dir, test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := DirectionFromDir64(dir64, test.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := directionColor(d, dir); got != want {
+				t.Fatalf("%d to %d: direction %d, expected a copy of %d, got %d", test.from, test.to, dir, want, got)
+			}
+
+			copies[want]++
+		}
+
+		for from, n := range copies {
+			// more directions repeat each one, fewer keep those that face the same way
+			if test.to > test.from && n != test.to/test.from {
+				t.Fatalf("%d to %d: direction %d is used %d times", test.from, test.to, from, n)
+			}
+
+			if test.to < test.from {
+				dir64, _ := DccToDir64(from, test.from)
+				if kept, _ := DirectionFromDir64(dir64, test.to); directionColor(d, kept) != from {
+					t.Fatalf("%d to %d: direction %d does not face the way it did", test.from, test.to, from)
+				}
+			}
+		}
+	}
+
+	d := colorDCC(t, 8, 1)
+	if err := d.ResampleDirections(12); !errors.Is(err, ErrUnsupportedDirectionCount) {
+		t.Fatalf("expected %v, got %v", ErrUnsupportedDirectionCount, err)
+	}
+}
+
+func TestInsertDirection(t *testing.T) {
+	d := colorDCC(t, 2, 2)
+	other := colorDCC(t, 3, 2)
+
+	if err := d.InsertDirection(1, other.Direction(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	for dir, want := range []int{0, 2, 1} {
+		if got := directionColor(d, dir); got != want {
+			t.Fatalf("direction %d, expected color %d, got %d", dir, want, got)
+		}
+	}
+
+	// the copy belongs to the DCC it was inserted into
+	if d.Direction(1) == other.Direction(2) || d.Direction(1).dcc != d {
+		t.Fatal("expected a copy of the direction")
+	}
+
+	if err := d.AddDirection(colorDCC(t, 1, 3).Direction(0)); !errors.Is(err, ErrFrameCountMismatch) {
+		t.Fatalf("expected %v, got %v", ErrFrameCountMismatch, err)
+	}
+
+	if err := d.InsertDirection(4, other.Direction(0)); !errors.Is(err, ErrDirectionOutOfRange) {
+		t.Fatalf("expected %v, got %v", ErrDirectionOutOfRange, err)
+	}
+}
+
+func TestReorderDirections(t *testing.T) {
+	d := colorDCC(t, 4, 1)
+
+	for _, order := range [][]int{
+		{0, 1, 2},
+		{0, 1, 2, 2},
+		{0, 1, 2, 4},
+		{-1, 1, 2, 3},
+	} {
+		if err := d.ReorderDirections(order); !errors.Is(err, ErrBadDirectionOrder) {
+			t.Fatalf("%v: expected %v, got %v", order, ErrBadDirectionOrder, err)
+		}
+	}
+
+	if err := d.ReorderDirections([]int{3, 0, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for dir, want := range []int{3, 0, 2, 1} {
+		if got := directionColor(d, dir); got != want {
+			t.Fatalf("direction %d, expected color %d, got %d", dir, want, got)
+		}
+	}
+}
+
+func TestRemoveDirection(t *testing.T) {
+	d := colorDCC(t, 2, 1)
+
+	if err := d.RemoveDirection(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Directions()) != 1 || directionColor(d, 0) != 1 {
+		t.Fatal("expected the second direction to be left")
+	}
+
+	if err := d.RemoveDirection(0); !errors.Is(err, ErrLastDirection) {
+		t.Fatalf("expected %v, got %v", ErrLastDirection, err)
+	}
+}
+
+func TestDirectionEditsResetOffsets(t *testing.T) {
+	for name, edit := range map[string]func(d *DCC) error{
+		"add":      func(d *DCC) error { return d.AddDirection(d.Direction(0)) },
+		"remove":   func(d *DCC) error { return d.RemoveDirection(0) },
+		"reorder":  func(d *DCC) error { return d.ReorderDirections([]int{3, 2, 1, 0}) },
+		"resample": func(d *DCC) error { return d.ResampleDirections(8) },
+	} {
+		d, _ := encodeDecode(t, colorDCC(t, 4, 2))
+
+		if len(d.DirectionOffsets) != 4 {
+			t.Fatalf("%s: expected the decoded direction offsets, got %v", name, d.DirectionOffsets)
+		}
+
+		if err := edit(d); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if d.DirectionOffsets != nil {
+			t.Fatalf("%s: expected the direction offsets to be reset, got %v", name, d.DirectionOffsets)
+		}
+
+		// the edited DCC encodes with the new directions
+		decoded, _ := encodeDecode(t, d)
+
+		if len(decoded.Directions()) != len(d.Directions()) || len(decoded.DirectionOffsets) != len(d.Directions()) {
+			t.Fatalf("%s: expected %d directions, got %d", name, len(d.Directions()), len(decoded.Directions()))
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package pkg
 
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
 type directionCount int
 
 const (
@@ -10,51 +16,226 @@ const (
 	sixtyFour
 )
 
-// Dir64ToDcc returns the DCC direction based on the actual direction.
+const (
+	one directionCount = 1
+)
+
+// numGameDirections is the number of directions the game uses internally,
+// every dcc direction count maps onto these
+const numGameDirections = int(sixtyFour)
+
+var (
+	// ErrUnsupportedDirectionCount is returned when a direction count
+	// is not one of 1, 4, 8, 16, 32 or 64
+	ErrUnsupportedDirectionCount = errors.New("unsupported direction count")
+	// ErrDirectionOutOfRange is returned when a direction index is outside
+	// of the range allowed by the direction count
+	ErrDirectionOutOfRange = errors.New("direction out of range")
+)
+
 // Special thanks for Necrolis for these tables!
-func Dir64ToDcc(direction, numDirections int) int {
-	var dir4 = [sixtyFour]int{
+var dirLookupTables = map[directionCount][sixtyFour]int{
+	one: {},
+	four: {
 		0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1,
 		1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2,
 		2, 2, 2, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3, 3, 3, 3,
-		3, 3, 3, 3, 3, 3, 3, 3, 0, 0, 0, 0, 0, 0, 0, 0}
-
-	var dir8 = [sixtyFour]int{
+		3, 3, 3, 3, 3, 3, 3, 3, 0, 0, 0, 0, 0, 0, 0, 0},
+	eight: {
 		4, 4, 4, 4, 0, 0, 0, 0, 0, 0, 0, 0, 5, 5, 5, 5,
 		5, 5, 5, 5, 1, 1, 1, 1, 1, 1, 1, 1, 6, 6, 6, 6,
 		6, 6, 6, 6, 2, 2, 2, 2, 2, 2, 2, 2, 7, 7, 7, 7,
-		7, 7, 7, 7, 3, 3, 3, 3, 3, 3, 3, 3, 4, 4, 4, 4}
-
-	var dir16 = [sixtyFour]int{
+		7, 7, 7, 7, 3, 3, 3, 3, 3, 3, 3, 3, 4, 4, 4, 4},
+	sixteen: {
 		4, 4, 8, 8, 8, 8, 0, 0, 0, 0, 9, 9, 9, 9, 5, 5,
 		5, 5, 10, 10, 10, 10, 1, 1, 1, 1, 11, 11, 11, 11, 6, 6,
 		6, 6, 12, 12, 12, 12, 2, 2, 2, 2, 13, 13, 13, 13, 7, 7,
-		7, 7, 14, 14, 14, 14, 3, 3, 3, 3, 15, 15, 15, 15, 4, 4}
-
-	var dir32 = [sixtyFour]int{
+		7, 7, 14, 14, 14, 14, 3, 3, 3, 3, 15, 15, 15, 15, 4, 4},
+	thirtyTwo: {
 		4, 16, 16, 8, 8, 17, 17, 0, 0, 18, 18, 9, 9, 19, 19, 5,
 		5, 20, 20, 10, 10, 21, 21, 1, 1, 22, 22, 11, 11, 23, 23, 6,
 		6, 24, 24, 12, 12, 25, 25, 2, 2, 26, 26, 13, 13, 27, 27, 7,
-		7, 28, 28, 14, 14, 29, 29, 3, 3, 30, 30, 15, 15, 31, 31, 4}
-
-	var dir64 = [sixtyFour]int{
+		7, 28, 28, 14, 14, 29, 29, 3, 3, 30, 30, 15, 15, 31, 31, 4},
+	sixtyFour: {
 		4, 32, 16, 33, 8, 34, 17, 35, 0, 36, 18, 37, 9, 38, 19, 39,
 		5, 40, 20, 41, 10, 42, 21, 43, 1, 44, 22, 45, 11, 46, 23, 47,
 		6, 48, 24, 49, 12, 50, 25, 51, 2, 52, 26, 53, 13, 54, 27, 55,
-		7, 56, 28, 57, 14, 58, 29, 59, 3, 60, 30, 61, 15, 62, 31, 63}
-
-	switch directionCount(numDirections) {
-	case four:
-		return dir4[direction]
-	case eight:
-		return dir8[direction]
-	case sixteen:
-		return dir16[direction]
-	case thirtyTwo:
-		return dir32[direction]
-	case sixtyFour:
-		return dir64[direction]
-	default:
+		7, 56, 28, 57, 14, 58, 29, 59, 3, 60, 30, 61, 15, 62, 31, 63},
+}
+
+// Dir64ToDcc returns the DCC direction based on the actual direction.
+// It yields 0 for unsupported direction counts or out of range directions,
+// use DirectionFromDir64 to get an error instead.
+func Dir64ToDcc(direction, numDirections int) int {
+	dccDirection, err := DirectionFromDir64(direction, numDirections)
+	if err != nil {
 		return 0
 	}
+
+	return dccDirection
+}
+
+// DirectionFromDir64 returns the DCC direction index for one of the
+// 64 directions used by the game (0 is south, increasing clockwise on screen).
+func DirectionFromDir64(direction, numDirections int) (int, error) {
+	table, err := dirLookupTable(numDirections)
+	if err != nil {
+		return 0, err
+	}
+
+	if direction < 0 || direction >= numGameDirections {
+		const fmtErr = "%w: game direction %d, expecting 0 to %d"
+		return 0, fmt.Errorf(fmtErr, ErrDirectionOutOfRange, direction, numGameDirections-1)
+	}
+
+	return table[direction], nil
+}
+
+// DccToDir64 returns the game direction (0 to 63) that the DCC direction
+// index is centered on. This is the inverse of DirectionFromDir64.
+func DccToDir64(dccDirection, numDirections int) (int, error) {
+	table, err := dirLookupTable(numDirections)
+	if err != nil {
+		return 0, err
+	}
+
+	if dccDirection < 0 || dccDirection >= numDirections {
+		const fmtErr = "%w: dcc direction %d, expecting 0 to %d"
+		return 0, fmt.Errorf(fmtErr, ErrDirectionOutOfRange, dccDirection, numDirections-1)
+	}
+
+	step := numGameDirections / numDirections
+
+	for dir64 := 0; dir64 < numGameDirections; dir64 += step {
+		if table[dir64] == dccDirection {
+			return dir64, nil
+		}
+	}
+
+	// unreachable for the tables above
+	return 0, fmt.Errorf("%w: dcc direction %d", ErrDirectionOutOfRange, dccDirection)
+}
+
+// DccToAngle returns the screen-space angle, in radians, that the DCC
+// direction index faces. See DirectionFromAngle for the angle convention.
+func DccToAngle(dccDirection, numDirections int) (float64, error) {
+	dir64, err := DccToDir64(dccDirection, numDirections)
+	if err != nil {
+		return 0, err
+	}
+
+	return dir64ToAngle(float64(dir64)), nil
+}
+
+// DirectionFromAngle returns the DCC direction index for a screen-space
+// angle in radians. Angles follow math.Atan2 with the y axis pointing down
+// the screen: 0 is east (right), Pi/2 is south (down), Pi is west (left).
+func DirectionFromAngle(angle float64, numDirections int) (int, error) {
+	if math.IsNaN(angle) || math.IsInf(angle, 0) {
+		return 0, fmt.Errorf("%w: angle %v", ErrDirectionOutOfRange, angle)
+	}
+
+	dir64 := int(math.Floor(angleToDir64(angle) + 0.5))
+
+	return DirectionFromDir64(wrap(dir64, numGameDirections), numDirections)
+}
+
+// DirectionFromVector returns the DCC direction index for a screen-space
+// vector, with x pointing right and y pointing down the screen. A zero
+// vector faces south.
+func DirectionFromVector(x, y float64, numDirections int) (int, error) {
+	if x == 0 && y == 0 {
+		y = 1
+	}
+
+	return DirectionFromAngle(math.Atan2(y, x), numDirections)
+}
+
+// DirectionOrder lists the DCC direction indices for the direction count in
+// clockwise order on screen, starting with direction 0. This is the order to
+// step through the directions in to rotate an animation smoothly.
+func DirectionOrder(numDirections int) ([]int, error) {
+	table, err := dirLookupTable(numDirections)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := DccToDir64(0, numDirections)
+	if err != nil {
+		return nil, err
+	}
+
+	step := numGameDirections / numDirections
+	order := make([]int, numDirections)
+
+	for idx := range order {
+		order[idx] = table[wrap(start+idx*step, numGameDirections)]
+	}
+
+	return order, nil
+}
+
+// MirrorDirection returns the DCC direction that faces the same way as the
+// given direction when flipped horizontally, for example west for east.
+// Directions facing straight north or south are their own mirror.
+func MirrorDirection(dccDirection, numDirections int) (int, error) {
+	table, err := dirLookupTable(numDirections)
+	if err != nil {
+		return 0, err
+	}
+
+	if dccDirection < 0 || dccDirection >= numDirections {
+		const fmtErr = "%w: dcc direction %d, expecting 0 to %d"
+		return 0, fmt.Errorf(fmtErr, ErrDirectionOutOfRange, dccDirection, numDirections-1)
+	}
+
+	if numDirections == int(one) {
+		return 0, nil
+	}
+
+	// the game directions of a dcc direction are a continuous run in the table,
+	// and flipping the screen horizontally turns game direction d into -d
+	start := 0
+	for table[start] != dccDirection || table[wrap(start-1, numGameDirections)] == dccDirection {
+		start++
+	}
+
+	length := 0
+	for table[wrap(start+length, numGameDirections)] == dccDirection {
+		length++
+	}
+
+	center := float64(start) + float64(length-1)/2 //nolint:gomnd // middle of the run
+	mirrored := int(math.Floor(-center))
+
+	return table[wrap(mirrored, numGameDirections)], nil
+}
+
+func dirLookupTable(numDirections int) (*[sixtyFour]int, error) {
+	table, found := dirLookupTables[directionCount(numDirections)]
+	if !found {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedDirectionCount, numDirections)
+	}
+
+	return &table, nil
+}
+
+// game direction 0 is south, which is Pi/2 in screen space
+func angleToDir64(angle float64) float64 {
+	return (angle - math.Pi/2) * float64(numGameDirections) / (2 * math.Pi)
+}
+
+func dir64ToAngle(dir64 float64) float64 {
+	return math.Mod(dir64*(2*math.Pi)/float64(numGameDirections)+math.Pi/2, 2*math.Pi)
+}
+
+// wrap integer to max: wrap(450, 360) == 90
+func wrap(x, max int) int {
+	wrapped := x % max
+
+	if wrapped < 0 {
+		return max + wrapped
+	}
+
+	return wrapped
 }
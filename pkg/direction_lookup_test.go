@@ -0,0 +1,183 @@
+package pkg
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+var testDirectionCounts = []int{1, 4, 8, 16, 32, 64}
+
+// directions of a dcc with 8 directions
+const (
+	testSouthWest = iota
+	testNorthWest
+	testNorthEast
+	testSouthEast
+	testSouth
+	testWest
+	testNorth
+	testEast
+)
+
+func TestDirectionRoundTrip(t *testing.T) {
+	for _, n := range testDirectionCounts {
+		for dir := 0; dir < n; dir++ {
+			dir64, err := DccToDir64(dir, n)
+			if err != nil {
+				t.Fatalf("%d of %d: %v", dir, n, err)
+			}
+
+			if got, err := DirectionFromDir64(dir64, n); err != nil || got != dir {
+				t.Fatalf("%d of %d: game direction %d gives %d, %v", dir, n, dir64, got, err)
+			}
+
+			angle, err := DccToAngle(dir, n)
+			if err != nil {
+				t.Fatalf("%d of %d: %v", dir, n, err)
+			}
+
+			if got, err := DirectionFromAngle(angle, n); err != nil || got != dir {
+				t.Fatalf("%d of %d: angle %v gives %d, %v", dir, n, angle, got, err)
+			}
+		}
+	}
+}
+
+func TestDirectionCardinals(t *testing.T) {
+	for _, test := range []struct {
+		x, y float64
+		dir  int
+	}{
+		{1, 0, testEast},
+		{1, 1, testSouthEast},
+		{0, 1, testSouth},
+		{-1, 1, testSouthWest},
+		{-1, 0, testWest},
+		{-1, -1, testNorthWest},
+		{0, -1, testNorth},
+		{1, -1, testNorthEast},
+		{0, 0, testSouth},
+	} {
+		if got, err := DirectionFromVector(test.x, test.y, 8); err != nil || got != test.dir {
+			t.Fatalf("vector (%v,%v): expected %d, got %d, %v", test.x, test.y, test.dir, got, err)
+		}
+
+		if test.x == 0 && test.y == 0 {
+			continue
+		}
+
+		angle := math.Atan2(test.y, test.x)
+
+		if got, err := DirectionFromAngle(angle, 8); err != nil || got != test.dir {
+			t.Fatalf("angle %v: expected %d, got %d, %v", angle, test.dir, got, err)
+		}
+
+		// a little off the exact angle still faces the same way
+		if got, _ := DirectionFromAngle(angle+0.1, 8); got != test.dir {
+			t.Fatalf("angle %v: expected %d, got %d", angle+0.1, test.dir, got)
+		}
+
+		faces, err := DccToAngle(test.dir, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := math.Abs(math.Remainder(faces-angle, 2*math.Pi)); diff > 1e-9 {
+			t.Fatalf("direction %d: expected angle %v, got %v", test.dir, angle, faces)
+		}
+	}
+}
+
+func TestDirectionLookupErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		fn   func() error
+		want error
+	}{
+		{"count", func() error { _, err := DirectionFromDir64(0, 5); return err }, ErrUnsupportedDirectionCount},
+		{"count", func() error { _, err := DccToDir64(0, 0); return err }, ErrUnsupportedDirectionCount},
+		{"count", func() error { _, err := DirectionFromAngle(0, 12); return err }, ErrUnsupportedDirectionCount},
+		{"count", func() error { _, err := MirrorDirection(0, 2); return err }, ErrUnsupportedDirectionCount},
+		{"count", func() error { _, err := DirectionOrder(128); return err }, ErrUnsupportedDirectionCount},
+		{"game direction", func() error { _, err := DirectionFromDir64(64, 8); return err }, ErrDirectionOutOfRange},
+		{"game direction", func() error { _, err := DirectionFromDir64(-1, 8); return err }, ErrDirectionOutOfRange},
+		{"dcc direction", func() error { _, err := DccToDir64(8, 8); return err }, ErrDirectionOutOfRange},
+		{"dcc direction", func() error { _, err := DccToAngle(-1, 8); return err }, ErrDirectionOutOfRange},
+		{"dcc direction", func() error { _, err := MirrorDirection(4, 4); return err }, ErrDirectionOutOfRange},
+		{"angle", func() error { _, err := DirectionFromAngle(math.NaN(), 8); return err }, ErrDirectionOutOfRange},
+		{"angle", func() error { _, err := DirectionFromAngle(math.Inf(1), 8); return err }, ErrDirectionOutOfRange},
+	} {
+		if err := test.fn(); !errors.Is(err, test.want) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.want, err)
+		}
+	}
+
+	if Dir64ToDcc(64, 8) != 0 || Dir64ToDcc(0, 5) != 0 {
+		t.Fatal("expected Dir64ToDcc to yield 0 on errors")
+	}
+}
+
+func TestMirrorDirection(t *testing.T) {
+	mirrors := map[int]int{
+		testSouthWest: testSouthEast,
+		testNorthWest: testNorthEast,
+		testWest:      testEast,
+		testSouth:     testSouth,
+		testNorth:     testNorth,
+	}
+
+	for dir, mirror := range mirrors {
+		for _, pair := range [][2]int{{dir, mirror}, {mirror, dir}} {
+			if got, err := MirrorDirection(pair[0], 8); err != nil || got != pair[1] {
+				t.Fatalf("expected %d to mirror %d, got %d, %v", pair[1], pair[0], got, err)
+			}
+		}
+	}
+
+	// every count mirrors back to where it started
+	for _, n := range testDirectionCounts {
+		for dir := 0; dir < n; dir++ {
+			mirror, err := MirrorDirection(dir, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if back, _ := MirrorDirection(mirror, n); back != dir {
+				t.Fatalf("%d of %d: mirrors to %d, which mirrors to %d", dir, n, mirror, back)
+			}
+		}
+	}
+}
+
+func TestDirectionOrder(t *testing.T) {
+	// the orders the viewer widget used before DirectionOrder
+	for n, want := range map[int][]int{
+		1:  {0},
+		4:  {0, 1, 2, 3},
+		8:  {0, 5, 1, 6, 2, 7, 3, 4},
+		16: {0, 9, 5, 10, 1, 11, 6, 12, 2, 13, 7, 14, 3, 15, 4, 8},
+	} {
+		if got, err := DirectionOrder(n); err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("%d directions: expected %v, got %v, %v", n, want, got, err)
+		}
+	}
+
+	// every direction is listed once
+	for _, n := range testDirectionCounts {
+		order, err := DirectionOrder(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		seen := make(map[int]bool)
+		for _, dir := range order {
+			seen[dir] = true
+		}
+
+		if len(order) != n || len(seen) != n {
+			t.Fatalf("%d directions: expected every direction once, got %v", n, order)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const maxChannel = 0xffff
+
+// Draw draws the frame onto dst using the palette of the DCC it belongs to.
+// The frame origin (the anchor at 0,0) is placed at the given point.
+func (f *Frame) Draw(dst draw.Image, at image.Point, mode DrawMode) {
+	DrawFrame(dst, at, f, f.palette(), mode)
+}
+
+// Image renders the frame into a new RGBA image, using the given draw mode
+// against a fully transparent background. The bounds of the image are the
+// same as the bounds of the frame.
+func (f *Frame) Image(mode DrawMode) *image.RGBA {
+	img := image.NewRGBA(f.Bounds())
+
+	f.Draw(img, image.Point{}, mode)
+
+	return img
+}
+
+// DrawFrame draws a frame onto dst with the given palette and draw mode.
+// The frame origin (the anchor at 0,0) is placed at the given point.
+// Palette index 0 is always treated as transparent.
+func DrawFrame(dst draw.Image, at image.Point, f *Frame, p color.Palette, mode DrawMode) {
+	src := f.Bounds()
+	clip := src.Add(at).Intersect(dst.Bounds())
+
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			idx := f.ColorIndexAt(x-at.X, y-at.Y)
+			if idx == 0 || int(idx) >= len(p) {
+				continue
+			}
+
+			dst.Set(x, y, blend(dst.At(x, y), p[idx], mode))
+		}
+	}
+}
+
+// blend composites the palette color src onto dst with the given mode.
+func blend(dst, src color.Color, mode DrawMode) color.Color {
+	sr, sg, sb, _ := src.RGBA()
+	dr, dg, db, da := dst.RGBA()
+
+	if mode == DrawModeAdditive {
+		r, g, b := clampAdd(dr, sr), clampAdd(dg, sg), clampAdd(db, sb)
+
+		// the alpha is raised to the brightest channel so that the result
+		// is still a valid alpha-premultiplied color
+		a := maxUint32(da, maxUint32(r, maxUint32(g, b)))
+
+		return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+	}
+
+	var a uint32
+
+	switch mode {
+	case DrawModeTrans25:
+		a = maxChannel * 3 / 4
+	case DrawModeTrans50:
+		a = maxChannel / 2
+	case DrawModeTrans75:
+		a = maxChannel / 4
+	case DrawModeLuminance:
+		a = maxUint32(sr, maxUint32(sg, sb))
+	default:
+		a = maxChannel
+	}
+
+	over := func(s, d uint32) uint16 {
+		return uint16((s*a + d*(maxChannel-a)) / maxChannel)
+	}
+
+	return color.RGBA64{
+		R: over(sr, dr),
+		G: over(sg, dg),
+		B: over(sb, db),
+		A: uint16(a + da*(maxChannel-a)/maxChannel),
+	}
+}
+
+func clampAdd(a, b uint32) uint32 {
+	if a+b > maxChannel {
+		return maxChannel
+	}
+
+	return a + b
+}
+
+func maxUint32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
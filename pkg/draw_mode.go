@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DrawMode describes how the pixels of a frame are blended with whatever
+// they are drawn on top of. These correspond to the draw modes the game
+// uses for missiles, spells, and overlays.
+type DrawMode int
+
+// Draw modes
+const (
+	// DrawModeNormal draws every non-transparent pixel fully opaque
+	DrawModeNormal DrawMode = iota
+	// DrawModeTrans25 draws with 25% transparency (75% opaque)
+	DrawModeTrans25
+	// DrawModeTrans50 draws with 50% transparency
+	DrawModeTrans50
+	// DrawModeTrans75 draws with 75% transparency (25% opaque)
+	DrawModeTrans75
+	// DrawModeAdditive adds the pixel colors to the destination ("light" blending)
+	DrawModeAdditive
+	// DrawModeLuminance uses the brightness of a pixel as its opacity, black is transparent
+	DrawModeLuminance
+)
+
+var drawModeNames = map[DrawMode]string{
+	DrawModeNormal:    "normal",
+	DrawModeTrans25:   "trans25",
+	DrawModeTrans50:   "trans50",
+	DrawModeTrans75:   "trans75",
+	DrawModeAdditive:  "additive",
+	DrawModeLuminance: "luminance",
+}
+
+func (m DrawMode) String() string {
+	s, ok := drawModeNames[m]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// DrawModeFromString returns the draw mode with the given name, as
+// yielded by DrawMode.String
+func DrawModeFromString(s string) (DrawMode, error) {
+	for mode, name := range drawModeNames {
+		if strings.EqualFold(name, s) {
+			return mode, nil
+		}
+	}
+
+	return DrawModeNormal, fmt.Errorf("unknown draw mode %q", s)
+}
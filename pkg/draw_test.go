@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+var (
+	testSource     = color.RGBA{R: 200, G: 100, B: 40, A: 0xff}
+	testBackground = color.RGBA{R: 40, G: 80, B: 120, A: 0xff}
+)
+
+// drawTestFrame returns a frame of two pixels, (0,-1) is drawn and (1,-1) is transparent
+func drawTestFrame() *Frame {
+	img := image.NewPaletted(image.Rect(0, -1, 2, 0), nil)
+	img.Pix[0] = 1
+
+	return NewFrame(img)
+}
+
+func closeColor(a, b color.RGBA) bool {
+	near := func(x, y uint8) bool { return x == y || x == y+1 || x+1 == y }
+
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+func TestDrawModes(t *testing.T) {
+	p := color.Palette{color.RGBA{}, testSource}
+
+	for _, test := range []struct {
+		mode DrawMode
+		want color.RGBA
+	}{
+		{DrawModeNormal, testSource},
+		{DrawModeAdditive, color.RGBA{R: 240, G: 180, B: 160, A: 0xff}},
+		// trans25 draws with 75% opacity, trans75 with 25%
+		{DrawModeTrans25, color.RGBA{R: 160, G: 95, B: 60, A: 0xff}},
+		{DrawModeTrans50, color.RGBA{R: 120, G: 90, B: 80, A: 0xff}},
+		{DrawModeTrans75, color.RGBA{R: 80, G: 85, B: 100, A: 0xff}},
+		// the brightest channel is the opacity, 200/255 here
+		{DrawModeLuminance, color.RGBA{R: 165, G: 95, B: 57, A: 0xff}},
+	} {
+		dst := image.NewRGBA(image.Rect(-2, -2, 2, 2))
+		draw.Draw(dst, dst.Rect, image.NewUniform(testBackground), image.Point{}, draw.Src)
+
+		DrawFrame(dst, image.Pt(-1, 0), drawTestFrame(), p, test.mode)
+
+		if got := dst.RGBAAt(-1, -1); !closeColor(got, test.want) {
+			t.Fatalf("%v: expected %v, got %v", test.mode, test.want, got)
+		}
+
+		// transparent pixels and pixels outside of the frame are left alone
+		for _, pt := range []image.Point{{0, -1}, {-1, 0}, {-2, -1}} {
+			if got := dst.RGBAAt(pt.X, pt.Y); got != testBackground {
+				t.Fatalf("%v: expected the background at %v, got %v", test.mode, pt, got)
+			}
+		}
+	}
+}
+
+func TestDrawOnTransparent(t *testing.T) {
+	d := newTestDCC(t, []*image.Paletted{drawTestFrame().Paletted()})
+	d.SetPalette(color.Palette{color.RGBA{}, testSource})
+
+	for _, test := range []struct {
+		mode DrawMode
+		want color.RGBA
+	}{
+		{DrawModeNormal, testSource},
+		// the result is alpha-premultiplied
+		{DrawModeTrans50, color.RGBA{R: 100, G: 50, B: 20, A: 0x7f}},
+		{DrawModeAdditive, color.RGBA{R: 200, G: 100, B: 40, A: 200}},
+	} {
+		img := d.Direction(0).Frame(0).Image(test.mode)
+
+		if img.Rect != image.Rect(0, -1, 2, 0) {
+			t.Fatalf("%v: expected the bounds of the frame, got %v", test.mode, img.Rect)
+		}
+
+		if got := img.RGBAAt(0, -1); !closeColor(got, test.want) {
+			t.Fatalf("%v: expected %v, got %v", test.mode, test.want, got)
+		}
+
+		if got := img.RGBAAt(1, -1); got != (color.RGBA{}) {
+			t.Fatalf("%v: expected a transparent pixel, got %v", test.mode, got)
+		}
+	}
+}
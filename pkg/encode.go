@@ -0,0 +1,589 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+	"sort"
+
+	"github.com/OpenDiablo2/bitstream"
+)
+
+const (
+	encodeVersion        = 6
+	maxCellColors        = 4
+	pixelMaskBits        = 4
+	maxDisplacement      = 15
+	maxStreamSize        = 1<<streamSizeBits - 1
+	maxDirections        = 1<<directionsBits - 1
+	dccHeaderBits        = signatureBits + versionBits + directionsBits + framesPerDirectionBits + sanityCheckBits + totalSizeCodedBits
+	directionHeaderBits  = 32
+	compressionFlagsBits = 2
+	bitWidthCodeBits     = 4
+)
+
+// Errors returned when encoding
+var (
+	ErrNoDirections   = errors.New("dcc has no directions")
+	ErrNoFrames       = errors.New("direction has no frames")
+	ErrTooLarge       = errors.New("too large to encode")
+	ErrFrameTooLarge  = errors.New("frame value does not fit in 32 bits")
+	ErrBottomUpFrames = errors.New("bottom up frames are not supported")
+)
+
+// bitBuffer collects bits, least significant bit first
+type bitBuffer struct {
+	bits bitstream.Bits
+}
+
+func (b *bitBuffer) put(v uint64, n int) {
+	for i := 0; i < n; i++ {
+		b.bits = append(b.bits, (v>>uint(i))&1 == 1)
+	}
+}
+
+func (b *bitBuffer) putBuffer(o *bitBuffer) {
+	b.bits = append(b.bits, o.bits...)
+}
+
+func (b *bitBuffer) bytes() []byte {
+	w := &bitstream.Writer{}
+	_, _ = w.WriteBits(b.bits)
+
+	return w.Bytes()
+}
+
+// Encode encodes the DCC. The header values that depend on the frames, like the
+// bit widths, substream sizes, palette entries and direction offsets, are
+// recalculated and stored in the DCC.
+//
+// A DCC cell of 4x4 pixels can only hold 4 colors, one of which is used for
+// transparency when the cell has transparent pixels. Cells with more colors are
+// reduced to the most common ones, and the other pixels are replaced with the
+// closest of those in the palette, so encoding is lossy for such frames.
+func (d *DCC) Encode() ([]byte, error) {
+	return d.EncodeWith(EncodeOptions{})
+}
+
+// EncodeOptions are the steps applied to the DCC before it is encoded
+type EncodeOptions struct {
+	// Trim shrinks every frame to its opaque bounds, see Frame.Trim
+	Trim bool
+}
+
+// EncodeWith applies the options to the DCC, then encodes it, see Encode
+func (d *DCC) EncodeWith(o EncodeOptions) ([]byte, error) {
+	if o.Trim {
+		d.Trim()
+	}
+
+	if len(d.directions) == 0 {
+		return nil, ErrNoDirections
+	}
+
+	if len(d.directions) > maxDirections {
+		return nil, fmt.Errorf("%w: %d directions", ErrTooLarge, len(d.directions))
+	}
+
+	encoded := make([][]byte, len(d.directions))
+	total := 0
+
+	for idx, dir := range d.directions {
+		if len(dir.frames) != len(d.directions[0].frames) {
+			return nil, fmt.Errorf("direction %d, %w", idx, ErrFrameCountMismatch)
+		}
+
+		data, err := dir.encode()
+		if err != nil {
+			return nil, fmt.Errorf("encoding direction %d, %w", idx, err)
+		}
+
+		encoded[idx] = data
+		total += len(data)
+	}
+
+	if d.Version == 0 {
+		d.Version = encodeVersion
+	}
+
+	d.numDirections = uint32(len(d.directions))
+	d.framesPerDirection = uint32(len(d.directions[0].frames))
+	d.TotalSizeCoded = uint32(total)
+	d.DirectionOffsets = make([]uint32, len(d.directions))
+
+	header := &bitBuffer{}
+	header.put(uint64(fileSignature), signatureBits)
+	header.put(uint64(d.Version), versionBits)
+	header.put(uint64(d.numDirections), directionsBits)
+	header.put(uint64(d.framesPerDirection), framesPerDirectionBits)
+	header.put(uint64(sanityCheck1), sanityCheckBits)
+	header.put(uint64(d.TotalSizeCoded), totalSizeCodedBits)
+
+	offset := (dccHeaderBits + directionOffsetBits*len(d.directions)) / bitsPerByte
+
+	for idx := range encoded {
+		d.DirectionOffsets[idx] = uint32(offset)
+		header.put(uint64(offset), directionOffsetBits)
+		offset += len(encoded[idx])
+	}
+
+	data := header.bytes()
+
+	for idx := range encoded {
+		data = append(data, encoded[idx]...)
+	}
+
+	d.dirty = false
+
+	return data, nil
+}
+
+// cellEncoder holds the state shared by the cells of a direction while encoding,
+// it mirrors what the decoder keeps so that equal cells can be reused
+type cellEncoder struct {
+	dir         *Direction
+	box         image.Rectangle
+	palette     color.Palette
+	entries     [numColorsInPalette]int // palette index -> palette entry
+	canvas      []byte                  // the pixels as the decoder will see them
+	last        []*Cell                 // the last frame cell written to each direction cell
+	equalCells  bitBuffer
+	pixelMask   bitBuffer
+	pixelValues bitBuffer
+	pixelCodes  bitBuffer
+}
+
+func (d *Direction) encode() ([]byte, error) {
+	if len(d.frames) == 0 {
+		return nil, ErrNoFrames
+	}
+
+	if d.Box == nil || !d.Box.Eq(d.frameBounds()) {
+		d.edit(func() {})
+	}
+
+	e := &cellEncoder{
+		dir:     d,
+		box:     *d.Box,
+		palette: d.frames[0].palette(),
+	}
+
+	d.calculatePaletteEntries(&e.entries)
+
+	d.HorizontalCellCount = 1 + (e.box.Dx()-1)/cellSize
+	d.VerticalCellCount = 1 + (e.box.Dy()-1)/cellSize
+	e.canvas = make([]byte, e.box.Dx()*e.box.Dy())
+	e.last = make([]*Cell, d.HorizontalCellCount*d.VerticalCellCount)
+
+	for idx, f := range d.frames {
+		if f.FrameIsBottomUp {
+			return nil, ErrBottomUpFrames
+		}
+
+		if err := f.recalculateCells(); err != nil {
+			return nil, fmt.Errorf("frame %d, %w", idx, err)
+		}
+
+		for cellIdx := range f.Cells {
+			e.encodeCell(f, &f.Cells[cellIdx])
+		}
+
+		f.Cells = nil
+	}
+
+	d.HorizontalCellCount, d.VerticalCellCount = 0, 0
+
+	frameHeaders, err := d.encodeFrameHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.equalCells.bits) > maxStreamSize || len(e.pixelMask.bits) > maxStreamSize {
+		return nil, fmt.Errorf("%w: substream is larger than %d bits", ErrTooLarge, maxStreamSize)
+	}
+
+	d.CompressionFlags = equalCellsCompression
+	d.EqualCellsBitstreamSize = uint32(len(e.equalCells.bits))
+	d.PixelMaskBitstreamSize = uint32(len(e.pixelMask.bits))
+	d.EncodingTypeBitstreamSize, d.RawPixelCodesBitstreamSize = 0, 0
+
+	body := &bitBuffer{}
+	body.putBuffer(frameHeaders)
+	body.put(uint64(d.EqualCellsBitstreamSize), streamSizeBits)
+	body.put(uint64(d.PixelMaskBitstreamSize), streamSizeBits)
+
+	for idx := 0; idx < numColorsInPalette; idx++ {
+		used := uint64(0)
+		if e.entries[idx] >= 0 {
+			used = 1
+		}
+
+		body.put(used, 1)
+	}
+
+	body.putBuffer(&e.equalCells)
+	body.putBuffer(&e.pixelMask)
+	body.putBuffer(&e.pixelValues)
+	body.putBuffer(&e.pixelCodes)
+
+	headerBits := directionHeaderBits + compressionFlagsBits + bitWidthCodeBits*7
+	d.OutSizeCoded = (headerBits + len(body.bits) + bitsPerByte - 1) / bitsPerByte
+
+	out := &bitBuffer{}
+	out.put(uint64(d.OutSizeCoded), directionHeaderBits)
+	out.put(uint64(d.CompressionFlags), compressionFlagsBits)
+
+	for _, width := range []int{
+		d.Variable0Bits, d.WidthBits, d.HeightBits, d.XOffsetBits,
+		d.YOffsetBits, d.OptionalDataBits, d.CodedBytesBits,
+	} {
+		out.put(uint64(crazyCode(width)), bitWidthCodeBits)
+	}
+
+	out.putBuffer(body)
+
+	return out.bytes(), nil
+}
+
+// frameBounds returns the union of the frame boxes
+func (d *Direction) frameBounds() image.Rectangle {
+	box := image.Rectangle{}
+
+	for idx, f := range d.frames {
+		if idx == 0 {
+			box = f.Box
+		} else {
+			box = box.Union(f.Box)
+		}
+	}
+
+	return box
+}
+
+// calculatePaletteEntries finds the palette indices used by the frames and numbers
+// them, unused indices are set to -1. Index 0 is always used, for transparency.
+func (d *Direction) calculatePaletteEntries(entries *[numColorsInPalette]int) {
+	used := [numColorsInPalette]bool{0: true}
+
+	for _, f := range d.frames {
+		for y := f.Box.Min.Y; y < f.Box.Max.Y; y++ {
+			for x := f.Box.Min.X; x < f.Box.Max.X; x++ {
+				used[f.ColorIndexAt(x, y)] = true
+			}
+		}
+	}
+
+	d.PaletteEntryCount = 0
+
+	for idx := range used {
+		entries[idx] = -1
+
+		if used[idx] {
+			entries[idx] = d.PaletteEntryCount
+			d.PaletteEntries[d.PaletteEntryCount] = byte(idx)
+			d.PaletteEntryCount++
+		}
+	}
+}
+
+func (e *cellEncoder) canvasIndex(x, y int) int {
+	return x + y*e.box.Dx()
+}
+
+func (e *cellEncoder) encodeCell(f *Frame, cell *Cell) {
+	pixels := make([]byte, cell.Width*cell.Height)
+
+	for y := 0; y < cell.Height; y++ {
+		for x := 0; x < cell.Width; x++ {
+			pixels[x+y*cell.Width] = f.ColorIndexAt(e.box.Min.X+cell.XOffset+x, e.box.Min.Y+cell.YOffset+y)
+		}
+	}
+
+	reduceCellColors(pixels, e.palette)
+
+	gridIdx := cell.XOffset/cellSize + (cell.YOffset/cellSize)*e.dir.HorizontalCellCount
+	last := e.last[gridIdx]
+	e.last[gridIdx] = cell
+
+	if last != nil {
+		if e.isEqualCell(cell, last, pixels) {
+			e.equalCells.put(1, 1)
+			e.draw(cell, pixels)
+
+			return
+		}
+
+		e.equalCells.put(0, 1)
+		e.pixelMask.put(0x0F, pixelMaskBits)
+	}
+
+	e.encodePixels(pixels)
+	e.draw(cell, pixels)
+}
+
+// isEqualCell returns true if the decoder will produce the pixels for an equal cell,
+// which copies the last cell when the sizes match, or clears the cell when they don't
+func (e *cellEncoder) isEqualCell(cell, last *Cell, pixels []byte) bool {
+	sameSize := cell.Width == last.Width && cell.Height == last.Height
+
+	for y := 0; y < cell.Height; y++ {
+		for x := 0; x < cell.Width; x++ {
+			want := byte(0)
+			if sameSize {
+				want = e.canvas[e.canvasIndex(last.XOffset+x, last.YOffset+y)]
+			}
+
+			if pixels[x+y*cell.Width] != want {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (e *cellEncoder) draw(cell *Cell, pixels []byte) {
+	for y := 0; y < cell.Height; y++ {
+		copy(e.canvas[e.canvasIndex(cell.XOffset, cell.YOffset+y):], pixels[y*cell.Width:(y+1)*cell.Width])
+	}
+}
+
+// encodePixels writes the colors of a cell as increasing displacements between
+// palette entries, followed by the index of each pixel into those colors
+func (e *cellEncoder) encodePixels(pixels []byte) {
+	colors := make([]int, 0, maxCellColors)
+	seen := [numColorsInPalette]bool{}
+
+	for _, p := range pixels {
+		if p != 0 && !seen[p] {
+			seen[p] = true
+			colors = append(colors, e.entries[p])
+		}
+	}
+
+	sort.Ints(colors)
+
+	last := 0
+
+	for _, c := range colors {
+		for displacement := c - last; ; displacement -= maxDisplacement {
+			if displacement < maxDisplacement {
+				e.pixelValues.put(uint64(displacement), pixelMaskBits)
+				break
+			}
+
+			e.pixelValues.put(maxDisplacement, pixelMaskBits)
+		}
+
+		last = c
+	}
+
+	if len(colors) < maxCellColors {
+		// a displacement of 0 repeats the last value, which ends the list
+		e.pixelValues.put(0, pixelMaskBits)
+	}
+
+	// the decoder stores the colors in reverse, with transparency after them
+	var values [maxCellColors]int
+
+	for idx, c := range colors {
+		values[len(colors)-1-idx] = c
+	}
+
+	if values[0] == values[1] {
+		return // a single color, no pixel codes
+	}
+
+	numBits := 2
+	if values[1] == values[2] {
+		numBits = 1
+	}
+
+	for _, p := range pixels {
+		entry := 0
+		if p != 0 {
+			entry = e.entries[p]
+		}
+
+		code := 0
+
+		for values[code] != entry {
+			code++
+		}
+
+		e.pixelCodes.put(uint64(code), numBits)
+	}
+}
+
+// reduceCellColors replaces the colors of a cell so that it has at most 4,
+// counting transparency. The most common colors are kept.
+func reduceCellColors(pixels []byte, p color.Palette) {
+	counts := make(map[byte]int)
+
+	for _, px := range pixels {
+		counts[px]++
+	}
+
+	if len(counts) <= maxCellColors {
+		return
+	}
+
+	colors := make([]byte, 0, len(counts))
+
+	for c := range counts {
+		if c != 0 {
+			colors = append(colors, c)
+		}
+	}
+
+	sort.Slice(colors, func(i, j int) bool {
+		if counts[colors[i]] != counts[colors[j]] {
+			return counts[colors[i]] > counts[colors[j]]
+		}
+
+		return colors[i] < colors[j]
+	})
+
+	numKept := maxCellColors
+	if counts[0] > 0 {
+		numKept--
+	}
+
+	kept := colors[:numKept]
+	replacement := make(map[byte]byte)
+
+	for _, c := range colors[numKept:] {
+		replacement[c] = closestColor(p, paletteColor(p, c), kept)
+	}
+
+	for idx, px := range pixels {
+		if r, found := replacement[px]; found {
+			pixels[idx] = r
+		}
+	}
+}
+
+// closestColor returns the candidate palette index with the color closest to c
+func closestColor(p color.Palette, c color.Color, candidates []byte) byte {
+	r, g, b := rgb8(c)
+	best, bestDistance := candidates[0], -1
+
+	for _, candidate := range candidates {
+		cr, cg, cb := rgb8(paletteColor(p, candidate))
+		dr, dg, db := int(cr)-int(r), int(cg)-int(g), int(cb)-int(b)
+		distance := dr*dr + dg*dg + db*db
+
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+func paletteColor(p color.Palette, idx byte) color.Color {
+	if int(idx) >= len(p) {
+		return color.Black
+	}
+
+	return p[idx]
+}
+
+func (d *Direction) encodeFrameHeaders() (*bitBuffer, error) {
+	maxWidth, maxHeight := 0, 0
+	xOffsetBits, yOffsetBits := 0, 0
+
+	for _, f := range d.frames {
+		if f.Width > maxWidth {
+			maxWidth = f.Width
+		}
+
+		if f.Height > maxHeight {
+			maxHeight = f.Height
+		}
+
+		if n := signedBits(f.XOffset); n > xOffsetBits {
+			xOffsetBits = n
+		}
+
+		if n := signedBits(f.YOffset); n > yOffsetBits {
+			yOffsetBits = n
+		}
+	}
+
+	widths := []struct {
+		field *int
+		bits  int
+	}{
+		{&d.Variable0Bits, 0},
+		{&d.WidthBits, bits.Len(uint(maxWidth))},
+		{&d.HeightBits, bits.Len(uint(maxHeight))},
+		{&d.XOffsetBits, xOffsetBits},
+		{&d.YOffsetBits, yOffsetBits},
+		{&d.OptionalDataBits, 0},
+		{&d.CodedBytesBits, 0},
+	}
+
+	for idx := range widths {
+		width, err := crazyWidth(widths[idx].bits)
+		if err != nil {
+			return nil, err
+		}
+
+		*widths[idx].field = width
+	}
+
+	out := &bitBuffer{}
+
+	for _, f := range d.frames {
+		f.NumberOfOptionalBytes, f.NumberOfCodedBytes = 0, 0
+
+		out.put(0, d.Variable0Bits)
+		out.put(uint64(f.Width), d.WidthBits)
+		out.put(uint64(f.Height), d.HeightBits)
+		out.put(uint64(f.XOffset), d.XOffsetBits)
+		out.put(uint64(f.YOffset), d.YOffsetBits)
+		out.put(0, d.OptionalDataBits)
+		out.put(0, d.CodedBytesBits)
+		out.put(0, 1) // not bottom up
+	}
+
+	return out, nil
+}
+
+// signedBits returns the number of bits needed to store v as a two's complement number
+func signedBits(v int) int {
+	if v == 0 {
+		return 0
+	}
+
+	if v < 0 {
+		v = -v - 1
+	}
+
+	return bits.Len(uint(v)) + 1
+}
+
+// crazyWidth returns the smallest bit width in the bit width table that holds n bits
+func crazyWidth(n int) (int, error) {
+	for _, width := range crazyBitTable {
+		if int(width) >= n {
+			return int(width), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %d bits", ErrFrameTooLarge, n)
+}
+
+// crazyCode returns the index of the bit width in the bit width table
+func crazyCode(width int) int {
+	for idx, w := range crazyBitTable {
+		if int(w) == width {
+			return idx
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+// regionImage returns an image where every 16x16 region, in the coordinates of
+// the anchor, has its own color. The frame boxes start on region boundaries, so
+// the cells hold at most one color and transparency, and encoding is lossless.
+func regionImage(r image.Rectangle, seed int) *image.Paletted {
+	const regionShift = 4
+
+	img := image.NewPaletted(r, nil)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			region := (x >> regionShift) + (y>>regionShift)*7 + seed*31
+			img.SetColorIndex(x, y, uint8(1+(region%255+255)%255))
+		}
+	}
+
+	return img
+}
+
+func encodeDecode(t *testing.T, d *DCC) (*DCC, []byte) {
+	t.Helper()
+
+	data, err := d.Encode()
+	if err != nil {
+		t.Fatalf("encoding, %v", err)
+	}
+
+	decoded, err := FromBytes(data)
+	if err != nil {
+		t.Fatalf("decoding, %v", err)
+	}
+
+	return decoded, data
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	boxes := []image.Rectangle{
+		image.Rect(-32, -48, -11, 5),
+		image.Rect(-16, -64, 19, -30),
+		image.Rect(0, -32, 37, 1),
+	}
+
+	directions := make([][]*image.Paletted, 4)
+
+	for dir := range directions {
+		for frame, box := range boxes {
+			directions[dir] = append(directions[dir], regionImage(box, dir*len(boxes)+frame))
+		}
+	}
+
+	d := newTestDCC(t, directions...)
+	decoded, _ := encodeDecode(t, d)
+
+	if len(decoded.Directions()) != len(directions) {
+		t.Fatalf("expected %d directions, got %d", len(directions), len(decoded.Directions()))
+	}
+
+	colors := make(map[uint8]bool)
+
+	for dir := range directions {
+		frames := decoded.Direction(dir).Frames()
+		if len(frames) != len(boxes) {
+			t.Fatalf("direction %d, expected %d frames, got %d", dir, len(boxes), len(frames))
+		}
+
+		for frame, want := range directions[dir] {
+			got := frames[frame].Paletted()
+
+			if got.Rect != want.Rect {
+				t.Fatalf("direction %d frame %d, expected box %v, got %v", dir, frame, want.Rect, got.Rect)
+			}
+
+			if !bytes.Equal(got.Pix, want.Pix) {
+				t.Fatalf("direction %d frame %d, pixels differ", dir, frame)
+			}
+
+			for _, idx := range got.Pix {
+				colors[idx] = true
+			}
+		}
+	}
+
+	const minColors = 16
+
+	if len(colors) <= minColors {
+		t.Fatalf("expected more than %d colors, got %d", minColors, len(colors))
+	}
+}
+
+func TestEncodeReusesEqualCells(t *testing.T) {
+	const numFrames = 8
+
+	// every cell has four colors of its own, so that the pixel data is large
+	img := image.NewPaletted(image.Rect(-32, -64, 32, 0), nil)
+
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			cell := x/cellSize + y/cellSize*img.Rect.Dx()/cellSize
+			img.Pix[x+y*img.Stride] = uint8(1 + (x%2+y%2*2+cell*4)%250)
+		}
+	}
+
+	repeated := make([]*image.Paletted, numFrames)
+	for idx := range repeated {
+		repeated[idx] = img
+	}
+
+	_, single := encodeDecode(t, newTestDCC(t, []*image.Paletted{img}))
+	decoded, many := encodeDecode(t, newTestDCC(t, repeated))
+
+	for idx, f := range decoded.Direction(0).Frames() {
+		if !bytes.Equal(f.Paletted().Pix, img.Pix) {
+			t.Fatalf("frame %d, pixels differ", idx)
+		}
+	}
+
+	// the repeated frames only cost a frame header and a bit per cell
+	if len(many) > len(single)*3/2 {
+		t.Fatalf("expected equal cells to be reused, %d frames take %d bytes, one takes %d",
+			numFrames, len(many), len(single))
+	}
+
+	if decoded.Direction(0).EqualCellsBitstreamSize == 0 {
+		t.Fatal("expected an equal cells bitstream")
+	}
+}
+
+func TestEncodeReducesCellColors(t *testing.T) {
+	// four cells of 4x4 pixels, with 5, 8 and 16 colors, and 6 colors with transparency
+	img := image.NewPaletted(image.Rect(-4, -8, 4, 0), nil)
+	colorsPerCell := []int{5, 8, 16, 6}
+
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			cell := x/cellSize + y/cellSize*2
+			idx := x%cellSize + y%cellSize*cellSize
+			img.Pix[x+y*img.Stride] = uint8(1 + cell*64 + idx%colorsPerCell[cell]*3)
+		}
+	}
+
+	img.Pix[img.PixOffset(3, -1)] = 0
+
+	decoded, _ := encodeDecode(t, newTestDCC(t, []*image.Paletted{img}))
+	got := decoded.Direction(0).Frame(0).Paletted()
+
+	for cell := range colorsPerCell {
+		box := image.Rect(-4, -8, 0, -4).Add(image.Pt(cell%2*cellSize, cell/2*cellSize))
+		wanted, kept := make(map[uint8]bool), make(map[uint8]bool)
+
+		for y := box.Min.Y; y < box.Max.Y; y++ {
+			for x := box.Min.X; x < box.Max.X; x++ {
+				wanted[img.ColorIndexAt(x, y)] = true
+			}
+		}
+
+		for y := box.Min.Y; y < box.Max.Y; y++ {
+			for x := box.Min.X; x < box.Max.X; x++ {
+				c := got.ColorIndexAt(x, y)
+
+				if !wanted[c] || (c == 0) != (img.ColorIndexAt(x, y) == 0) {
+					t.Fatalf("cell %d, (%d,%d) has color %d, which was not in the cell", cell, x, y, c)
+				}
+
+				kept[c] = true
+			}
+		}
+
+		if len(kept) > maxCellColors {
+			t.Fatalf("cell %d, expected at most %d colors, got %d", cell, maxCellColors, len(kept))
+		}
+	}
+}
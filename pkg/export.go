@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+)
+
+const (
+	alphaThreshold = 0x8000
+)
+
+// Sheet renders every frame of every direction into a single sprite sheet.
+// Each row is a direction, each column is a frame. All cells have the size
+// of the union of the direction boxes, so the anchors line up across cells.
+func (d *DCC) Sheet(mode DrawMode) *image.RGBA {
+	cell := d.bounds()
+	numFrames := int(d.framesPerDirection)
+
+	sheet := image.NewRGBA(image.Rect(0, 0, cell.Dx()*numFrames, cell.Dy()*len(d.directions)))
+
+	for dirIdx, dir := range d.directions {
+		for frameIdx, frame := range dir.frames {
+			cellOrigin := image.Point{X: frameIdx * cell.Dx(), Y: dirIdx * cell.Dy()}
+			frame.Draw(sheet, cellOrigin.Sub(cell.Min), mode)
+		}
+	}
+
+	return sheet
+}
+
+// FrameImage renders frame n of the direction into a new RGBA image with the
+// bounds of the direction box, so that the anchors of all frames line up. Nil
+// is returned if there is no frame n.
+func (d *Direction) FrameImage(n int, mode DrawMode) *image.RGBA {
+	f := d.Frame(n)
+	if f == nil {
+		return nil
+	}
+
+	img := image.NewRGBA(d.Bounds())
+	f.Draw(img, image.Point{}, mode)
+
+	return img
+}
+
+// bounds returns the union of all direction boxes
+func (d *DCC) bounds() image.Rectangle {
+	r := image.Rectangle{}
+
+	for _, dir := range d.directions {
+		r = r.Union(dir.Bounds())
+	}
+
+	return r
+}
+
+// GIF renders the frames of the direction as an animated GIF. The delay is
+// the time between frames in 100ths of a second. Because GIF has no partial
+// transparency, pixels that end up more than half transparent after drawing
+// with the given mode are written as transparent (palette index 0).
+func (d *Direction) GIF(mode DrawMode, delay int) *gif.GIF {
+	box := d.Bounds()
+	size := image.Rect(0, 0, box.Dx(), box.Dy())
+
+	p := make(color.Palette, len(*d.dcc.palette))
+	copy(p, *d.dcc.palette)
+	p[0] = color.RGBA{}
+
+	g := &gif.GIF{
+		Config: image.Config{
+			ColorModel: p,
+			Width:      size.Dx(),
+			Height:     size.Dy(),
+		},
+	}
+
+	for _, frame := range d.frames {
+		rgba := image.NewRGBA(size)
+		frame.Draw(rgba, box.Min.Mul(-1), mode)
+
+		g.Image = append(g.Image, quantize(rgba, p))
+		g.Delay = append(g.Delay, delay)
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+	}
+
+	return g
+}
+
+// quantize maps an RGBA image onto the palette, index 0 is used for
+// transparent pixels.
+func quantize(src *image.RGBA, p color.Palette) *image.Paletted {
+	dst := image.NewPaletted(src.Bounds(), p)
+
+	// only index 1 and upward are considered for opaque pixels
+	opaque := p[1:]
+
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			r, g, b, a := src.At(x, y).RGBA()
+			if a < alphaThreshold {
+				continue
+			}
+
+			// un-premultiply before matching against the palette
+			straight := color.RGBA64{
+				R: uint16(r * maxChannel / a),
+				G: uint16(g * maxChannel / a),
+				B: uint16(b * maxChannel / a),
+				A: maxChannel,
+			}
+
+			dst.SetColorIndex(x, y, uint8(1+opaque.Index(straight)))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// exportTestPalette gives every palette index its own color
+func exportTestPalette() color.Palette {
+	p := make(color.Palette, numColorsInPalette)
+
+	for idx := range p {
+		p[idx] = color.RGBA{R: uint8(idx), G: uint8(0xff - idx), B: uint8(idx / 2), A: 0xff}
+	}
+
+	return p
+}
+
+// exportTestDCC returns two directions of three frames, frame f of direction d
+// is filled with color 1+d*3+f
+func exportTestDCC(t *testing.T) *DCC {
+	t.Helper()
+
+	boxes := [][]image.Rectangle{
+		{image.Rect(-4, -10, 3, 0), image.Rect(-2, -12, 5, -1), image.Rect(-6, -8, 0, 1)},
+		{image.Rect(0, -5, 8, 0), image.Rect(-1, -6, 2, -2), image.Rect(-3, -4, 1, 2)},
+	}
+
+	directions := make([][]*image.Paletted, len(boxes))
+
+	for dir, frames := range boxes {
+		for frame, box := range frames {
+			directions[dir] = append(directions[dir], filledImage(box, uint8(1+dir*len(frames)+frame)))
+		}
+	}
+
+	d := newTestDCC(t, directions...)
+	d.SetPalette(exportTestPalette())
+
+	return d
+}
+
+func TestSheet(t *testing.T) {
+	d := exportTestDCC(t)
+	p := exportTestPalette()
+
+	// the cells have the size of the union of the direction boxes
+	cell := image.Rect(-6, -12, 8, 2)
+	sheet := d.Sheet(DrawModeNormal)
+
+	if sheet.Rect != image.Rect(0, 0, 3*cell.Dx(), 2*cell.Dy()) {
+		t.Fatalf("expected a sheet of 3x2 cells of %v, got %v", cell.Size(), sheet.Rect)
+	}
+
+	for dir, direction := range d.Directions() {
+		for frame, f := range direction.Frames() {
+			origin := image.Pt(frame*cell.Dx(), dir*cell.Dy()).Sub(cell.Min)
+			want := p[1+dir*3+frame]
+
+			for _, pt := range []image.Point{f.Box.Min, f.Box.Max.Sub(image.Pt(1, 1))} {
+				if got := sheet.At(origin.X+pt.X, origin.Y+pt.Y); got != want {
+					t.Fatalf("direction %d frame %d, expected %v at %v, got %v", dir, frame, want, pt, got)
+				}
+			}
+
+			// the corner of the cell is outside of every frame
+			if _, _, _, a := sheet.At(origin.X+cell.Min.X, origin.Y+cell.Min.Y).RGBA(); a != 0 {
+				t.Fatalf("direction %d frame %d, expected a transparent corner", dir, frame)
+			}
+		}
+	}
+}
+
+func TestFrameImage(t *testing.T) {
+	dir := exportTestDCC(t).Direction(1)
+
+	img := dir.FrameImage(1, DrawModeNormal)
+	if img.Rect != dir.Bounds() {
+		t.Fatalf("expected the direction box %v, got %v", dir.Bounds(), img.Rect)
+	}
+
+	if img.At(-1, -6) != exportTestPalette()[5] {
+		t.Fatalf("expected the frame at its anchor, got %v", img.At(-1, -6))
+	}
+
+	if dir.FrameImage(3, DrawModeNormal) != nil {
+		t.Fatal("expected no image for a frame out of range")
+	}
+}
+
+func TestGIF(t *testing.T) {
+	const delay = 7
+
+	dir := exportTestDCC(t).Direction(0)
+	box := dir.Bounds()
+
+	g := dir.GIF(DrawModeNormal, delay)
+
+	if len(g.Image) != 3 || len(g.Delay) != 3 || len(g.Disposal) != 3 {
+		t.Fatalf("expected 3 frames, got %d images and %d delays", len(g.Image), len(g.Delay))
+	}
+
+	if g.Config.Width != box.Dx() || g.Config.Height != box.Dy() {
+		t.Fatalf("expected the size of the direction box %v, got %dx%d", box.Size(), g.Config.Width, g.Config.Height)
+	}
+
+	for frame, img := range g.Image {
+		if g.Delay[frame] != delay {
+			t.Fatalf("frame %d, expected a delay of %d, got %d", frame, delay, g.Delay[frame])
+		}
+
+		f := dir.Frame(frame)
+		at := f.Box.Min.Sub(box.Min)
+
+		if idx := img.ColorIndexAt(at.X, at.Y); idx != uint8(1+frame) {
+			t.Fatalf("frame %d, expected palette index %d at the top left, got %d", frame, 1+frame, idx)
+		}
+
+		// no frame covers the corner of the direction box
+		if idx := img.ColorIndexAt(0, 0); idx != 0 {
+			t.Fatalf("frame %d, expected the corner to be transparent, got %d", frame, idx)
+		}
+	}
+
+	// pixels that are more than half transparent are left out
+	at := dir.Frame(0).Box.Min.Sub(box.Min)
+
+	if idx := dir.GIF(DrawModeTrans75, delay).Image[0].ColorIndexAt(at.X, at.Y); idx != 0 {
+		t.Fatalf("expected trans75 pixels to be transparent, got %d", idx)
+	}
+
+	if idx := dir.GIF(DrawModeTrans25, delay).Image[0].ColorIndexAt(at.X, at.Y); idx == 0 {
+		t.Fatal("expected trans25 pixels to be opaque")
+	}
+}
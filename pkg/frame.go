@@ -28,24 +28,28 @@ type Frame struct {
 }
 
 func (f *Frame) decodeFrameHeader(stream *bitstream.Reader) (err error) {
+	header := func(field string, n int) bitstream.Response {
+		return f.direction.dcc.next(stream, TraceHeaderField, StreamHeader, field, n)
+	}
+
 	// we dont use var0 width bits
-	_, _ = stream.Next(f.direction.Variable0Bits).Bits().AsUInt32()
+	_, _ = header("Variable0", f.direction.Variable0Bits).AsUInt32()
 
-	width, _ := stream.Next(f.direction.WidthBits).Bits().AsUInt32()
-	height, _ := stream.Next(f.direction.HeightBits).Bits().AsUInt32()
+	width, _ := header("Width", f.direction.WidthBits).AsUInt32()
+	height, _ := header("Height", f.direction.HeightBits).AsUInt32()
 	f.Width, f.Height = int(width), int(height)
 
-	f.XOffset, _ = stream.Next(f.direction.XOffsetBits).Bits().AsInt()
-	f.YOffset, _ = stream.Next(f.direction.YOffsetBits).Bits().AsInt()
+	f.XOffset, _ = header("XOffset", f.direction.XOffsetBits).AsInt()
+	f.YOffset, _ = header("YOffset", f.direction.YOffsetBits).AsInt()
 
-	numOptionBytes, _ := stream.Next(f.direction.OptionalDataBits).Bits().AsUInt32()
+	numOptionBytes, _ := header("NumberOfOptionalBytes", f.direction.OptionalDataBits).AsUInt32()
 	f.NumberOfOptionalBytes = int(numOptionBytes)
 
-	codedBytes, _ := stream.Next(f.direction.CodedBytesBits).Bits().AsUInt32()
+	codedBytes, _ := header("NumberOfCodedBytes", f.direction.CodedBytesBits).AsUInt32()
 	f.NumberOfCodedBytes = int(codedBytes)
 
 	// we will finally use the last returned stream error.
-	f.FrameIsBottomUp, err = stream.Next(1).Bits().AsBool()
+	f.FrameIsBottomUp, err = header("FrameIsBottomUp", 1).AsBool()
 	if err != nil {
 		return fmt.Errorf("stream error, %w", err)
 	}
@@ -89,11 +93,12 @@ func (f *Frame) calcCellCounts() {
 		f.VerticalCellCount--
 	}
 
-	if f.HorizontalCellCount <= 0 {
+	// frames that end within a pixel of the first cell only have one cell
+	if remainderW < 0 {
 		f.HorizontalCellCount = 1
 	}
 
-	if f.VerticalCellCount <= 0 {
+	if remainderH < 0 {
 		f.VerticalCellCount = 1
 	}
 }
@@ -0,0 +1,246 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// Errors returned when editing frames
+var (
+	ErrFrameOutOfRange = errors.New("frame index out of range")
+	ErrLastFrame       = errors.New("cannot remove the only frame")
+)
+
+// NewFrame creates a frame from a paletted image. The pixel values are indices
+// into the DCC palette, index 0 is transparent. The image bounds are relative
+// to the frame anchor, so the anchor is at 0,0. The frame does not belong to a
+// direction until it is given to SetFrame or InsertFrame.
+func NewFrame(img *image.Paletted) *Frame {
+	f := &Frame{}
+
+	f.setPixels(img)
+
+	return f
+}
+
+// SetPixels replaces the pixels of the frame with those of the image, see NewFrame.
+// The frame box and the box of its direction are updated to match.
+func (f *Frame) SetPixels(img *image.Paletted) {
+	d := f.direction
+	if d == nil {
+		f.setPixels(img)
+		return
+	}
+
+	d.edit(func() {
+		f.setPixels(img)
+	})
+}
+
+func (f *Frame) setPixels(img *image.Paletted) {
+	bounds := img.Bounds()
+
+	f.Box = bounds
+	f.Width, f.Height = bounds.Dx(), bounds.Dy()
+	f.XOffset, f.YOffset = bounds.Min.X, bounds.Max.Y-1
+	f.NumberOfOptionalBytes, f.NumberOfCodedBytes = 0, 0
+	f.Cells = nil
+	f.PixelData = make([]byte, f.Width*f.Height)
+	f.valid = true
+
+	for y := 0; y < f.Height; y++ {
+		copy(f.PixelData[y*f.Width:(y+1)*f.Width], img.Pix[img.PixOffset(bounds.Min.X, bounds.Min.Y+y):])
+	}
+}
+
+// Paletted returns a copy of the frame as a paletted image, using the DCC palette
+func (f *Frame) Paletted() *image.Paletted {
+	img := image.NewPaletted(f.Box, f.palette())
+
+	for y := f.Box.Min.Y; y < f.Box.Max.Y; y++ {
+		for x := f.Box.Min.X; x < f.Box.Max.X; x++ {
+			img.SetColorIndex(x, y, f.ColorIndexAt(x, y))
+		}
+	}
+
+	return img
+}
+
+// framePixels returns the pixel data laid out over the frame box
+func (f *Frame) framePixels() []byte {
+	pixels := make([]byte, f.Box.Dx()*f.Box.Dy())
+
+	for y := f.Box.Min.Y; y < f.Box.Max.Y; y++ {
+		for x := f.Box.Min.X; x < f.Box.Max.X; x++ {
+			pixels[(x-f.Box.Min.X)+(y-f.Box.Min.Y)*f.Box.Dx()] = f.ColorIndexAt(x, y)
+		}
+	}
+
+	return pixels
+}
+
+// detach lays the pixel data out over the frame box, and removes the frame from its direction
+func (f *Frame) detach() {
+	if f.direction == nil {
+		return
+	}
+
+	f.PixelData = f.framePixels()
+	f.direction = nil
+}
+
+// attach lays the pixel data of a detached frame out over the direction box
+func (f *Frame) attach(d *Direction) {
+	box := *d.Box
+	pixels := make([]byte, box.Dx()*box.Dy())
+
+	for y := f.Box.Min.Y; y < f.Box.Max.Y; y++ {
+		for x := f.Box.Min.X; x < f.Box.Max.X; x++ {
+			pixels[(x-box.Min.X)+(y-box.Min.Y)*box.Dx()] = f.ColorIndexAt(x, y)
+		}
+	}
+
+	f.PixelData = pixels
+	f.direction = d
+}
+
+// clone returns a detached copy of the frame
+func (f *Frame) clone() *Frame {
+	return &Frame{
+		Box:                   f.Box,
+		Width:                 f.Width,
+		Height:                f.Height,
+		XOffset:               f.XOffset,
+		YOffset:               f.YOffset,
+		NumberOfOptionalBytes: f.NumberOfOptionalBytes,
+		NumberOfCodedBytes:    f.NumberOfCodedBytes,
+		FrameIsBottomUp:       f.FrameIsBottomUp,
+		valid:                 f.valid,
+		PixelData:             f.framePixels(),
+	}
+}
+
+// blankFrame returns a detached frame with a single transparent pixel at the anchor
+func blankFrame() *Frame {
+	return NewFrame(image.NewPaletted(image.Rect(0, 0, 1, 1), nil))
+}
+
+// edit detaches every frame, calls fn to change them, then recalculates the
+// direction box and lays the frames out over it again
+func (d *Direction) edit(fn func()) {
+	for _, f := range d.frames {
+		f.detach()
+	}
+
+	fn()
+
+	box := image.Rectangle{}
+
+	for idx, f := range d.frames {
+		if idx == 0 {
+			box = f.Box
+		} else {
+			box = box.Union(f.Box)
+		}
+	}
+
+	d.Box = &box
+
+	for _, f := range d.frames {
+		f.attach(d)
+	}
+
+	d.Cells, d.PixelData, d.PixelBuffer = nil, nil, nil
+	d.HorizontalCellCount, d.VerticalCellCount = 0, 0
+
+	if d.dcc != nil {
+		d.dcc.framesPerDirection = uint32(len(d.frames))
+		d.dcc.dirty = true
+	}
+}
+
+// siblings returns every direction of the DCC that the direction belongs to
+func (d *Direction) siblings() []*Direction {
+	if d.dcc == nil {
+		return []*Direction{d}
+	}
+
+	return d.dcc.directions
+}
+
+// SetFrame replaces frame n with a copy of f, which can belong to any direction
+func (d *Direction) SetFrame(n int, f *Frame) error {
+	if n < 0 || n >= len(d.frames) {
+		return fmt.Errorf("%w, %d", ErrFrameOutOfRange, n)
+	}
+
+	c := f.clone()
+
+	d.edit(func() {
+		d.frames[n] = c
+	})
+
+	return nil
+}
+
+// InsertFrame inserts a copy of f before frame n, or after the last frame if n is
+// the number of frames. Every direction of a DCC has the same number of frames,
+// so a blank frame is inserted at the same position in the other directions.
+func (d *Direction) InsertFrame(n int, f *Frame) error {
+	if n < 0 || n > len(d.frames) {
+		return fmt.Errorf("%w, %d", ErrFrameOutOfRange, n)
+	}
+
+	c := f.clone()
+
+	for _, dir := range d.siblings() {
+		inserted := c
+		if dir != d {
+			inserted = blankFrame()
+		}
+
+		dir.edit(func() {
+			dir.frames = append(dir.frames[:n], append([]*Frame{inserted}, dir.frames[n:]...)...)
+		})
+	}
+
+	return nil
+}
+
+// RemoveFrame removes frame n. Every direction of a DCC has the same number of
+// frames, so the frame is removed from the other directions as well.
+func (d *Direction) RemoveFrame(n int) error {
+	if n < 0 || n >= len(d.frames) {
+		return fmt.Errorf("%w, %d", ErrFrameOutOfRange, n)
+	}
+
+	if len(d.frames) == 1 {
+		return ErrLastFrame
+	}
+
+	for _, dir := range d.siblings() {
+		dir.edit(func() {
+			dir.frames = append(dir.frames[:n], dir.frames[n+1:]...)
+		})
+	}
+
+	return nil
+}
+
+// SwapFrames swaps frames a and b of the direction
+func (d *Direction) SwapFrames(a, b int) error {
+	for _, n := range []int{a, b} {
+		if n < 0 || n >= len(d.frames) {
+			return fmt.Errorf("%w, %d", ErrFrameOutOfRange, n)
+		}
+	}
+
+	d.frames[a], d.frames[b] = d.frames[b], d.frames[a]
+
+	if d.dcc != nil {
+		d.dcc.dirty = true
+	}
+
+	return nil
+}
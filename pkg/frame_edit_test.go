@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+// framesOf returns copies of the frames of every direction
+func framesOf(d *DCC) [][]*image.Paletted {
+	result := make([][]*image.Paletted, len(d.Directions()))
+
+	for dir, direction := range d.Directions() {
+		for _, f := range direction.Frames() {
+			result[dir] = append(result[dir], f.Paletted())
+		}
+	}
+
+	return result
+}
+
+func checkFrame(t *testing.T, name string, got *Frame, want *image.Paletted) {
+	t.Helper()
+
+	img := got.Paletted()
+
+	if img.Rect != want.Rect || !reflect.DeepEqual(img.Pix, want.Pix) {
+		t.Fatalf("%s: expected the frame %v, got %v", name, want.Rect, img.Rect)
+	}
+}
+
+func TestInsertFrame(t *testing.T) {
+	d := newTestDCC(t,
+		[]*image.Paletted{numberedImage(image.Rect(-4, -8, 4, 0)), numberedImage(image.Rect(-2, -3, 5, 1))},
+		[]*image.Paletted{numberedImage(image.Rect(-1, -1, 1, 0)), numberedImage(image.Rect(0, -6, 3, 0))},
+	)
+	before := framesOf(d)
+
+	inserted := numberedImage(image.Rect(-9, -12, -5, -10))
+
+	if err := d.Direction(0).InsertFrame(1, NewFrame(inserted)); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.framesPerDirection != 3 || len(d.Direction(1).Frames()) != 3 {
+		t.Fatalf("expected 3 frames in every direction, got %d", d.framesPerDirection)
+	}
+
+	checkFrame(t, "inserted", d.Direction(0).Frame(1), inserted)
+	checkFrame(t, "blank", d.Direction(1).Frame(1), image.NewPaletted(image.Rect(0, 0, 1, 1), nil))
+
+	for dir := range before {
+		checkFrame(t, "first", d.Direction(dir).Frame(0), before[dir][0])
+		checkFrame(t, "moved", d.Direction(dir).Frame(2), before[dir][1])
+	}
+
+	// after the last frame
+	if err := d.Direction(1).InsertFrame(3, NewFrame(inserted)); err != nil {
+		t.Fatal(err)
+	}
+
+	checkFrame(t, "appended", d.Direction(1).Frame(3), inserted)
+
+	for _, n := range []int{-1, 5} {
+		if err := d.Direction(0).InsertFrame(n, NewFrame(inserted)); !errors.Is(err, ErrFrameOutOfRange) {
+			t.Fatalf("frame %d, expected %v, got %v", n, ErrFrameOutOfRange, err)
+		}
+	}
+}
+
+func TestRemoveFrame(t *testing.T) {
+	d := newTestDCC(t,
+		[]*image.Paletted{numberedImage(image.Rect(-4, -8, 4, 0)), numberedImage(image.Rect(-2, -3, 5, 1))},
+		[]*image.Paletted{numberedImage(image.Rect(-1, -1, 1, 0)), numberedImage(image.Rect(0, -6, 3, 0))},
+	)
+	before := framesOf(d)
+
+	if err := d.Direction(1).RemoveFrame(2); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Fatalf("expected %v, got %v", ErrFrameOutOfRange, err)
+	}
+
+	if err := d.Direction(1).RemoveFrame(0); err != nil {
+		t.Fatal(err)
+	}
+
+	for dir := range before {
+		if len(d.Direction(dir).Frames()) != 1 {
+			t.Fatalf("direction %d, expected one frame, got %d", dir, len(d.Direction(dir).Frames()))
+		}
+
+		checkFrame(t, "left", d.Direction(dir).Frame(0), before[dir][1])
+	}
+
+	if err := d.Direction(0).RemoveFrame(0); !errors.Is(err, ErrLastFrame) {
+		t.Fatalf("expected %v, got %v", ErrLastFrame, err)
+	}
+}
+
+func TestSetFrameRelaysPixels(t *testing.T) {
+	d := newTestDCC(t, []*image.Paletted{
+		numberedImage(image.Rect(-4, -8, 4, 0)),
+		numberedImage(image.Rect(-2, -3, 5, 1)),
+		numberedImage(image.Rect(-3, -6, 0, -2)),
+	})
+	before := framesOf(d)
+	dir := d.Direction(0)
+
+	for _, test := range []struct {
+		name  string
+		frame *image.Paletted
+		box   image.Rectangle
+	}{
+		{"grown", numberedImage(image.Rect(-20, -30, 12, 8)), image.Rect(-20, -30, 12, 8)},
+		{"shrunk", numberedImage(image.Rect(-1, -1, 1, 0)), image.Rect(-3, -6, 5, 1)},
+	} {
+		if err := dir.SetFrame(0, NewFrame(test.frame)); err != nil {
+			t.Fatal(err)
+		}
+
+		if *dir.Box != test.box {
+			t.Fatalf("%s: expected the direction box %v, got %v", test.name, test.box, *dir.Box)
+		}
+
+		checkFrame(t, test.name, dir.Frame(0), test.frame)
+
+		// the other frames keep their pixels where they were
+		for idx := 1; idx < len(before[0]); idx++ {
+			checkFrame(t, test.name, dir.Frame(idx), before[0][idx])
+
+			if len(dir.Frame(idx).PixelData) != test.box.Dx()*test.box.Dy() {
+				t.Fatalf("%s: expected the pixels of frame %d to cover the direction box", test.name, idx)
+			}
+		}
+	}
+
+	if err := dir.SwapFrames(1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	checkFrame(t, "swapped", dir.Frame(1), before[0][2])
+
+	if err := dir.SetFrame(3, NewFrame(before[0][0])); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Fatalf("expected %v, got %v", ErrFrameOutOfRange, err)
+	}
+}
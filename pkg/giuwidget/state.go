@@ -11,9 +11,9 @@ import (
 	"github.com/AllenDang/giu"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
-)
 
-const miliseconds = 1000
+	dcclib "github.com/OpenDiablo2/dcc/pkg"
+)
 
 type animationPlayMode byte
 
@@ -38,8 +38,25 @@ func (a animationPlayMode) String() string {
 	return k
 }
 
+func (a animationPlayMode) toPlayMode() dcclib.PlayMode {
+	switch a {
+	case playModeBackword:
+		return dcclib.PlayBackward
+	case playModePingPong:
+		return dcclib.PlayPingPong
+	default:
+		return dcclib.PlayForward
+	}
+}
+
 const defaultTickTime = 100
 
+// loadedTexture is sent by the texture loader once the texture of a frame is ready
+type loadedTexture struct {
+	index   int
+	texture *giu.Texture
+}
+
 type widgetState struct {
 	controls struct {
 		direction int32
@@ -56,8 +73,11 @@ type widgetState struct {
 	images   []*image.RGBA
 	textures []*giu.Texture
 
-	isForward bool // determines a direction of animation
-	ticker    *time.Ticker
+	// the state is only ever touched from the render thread: the player is
+	// stepped in Build, and the textures are handed over through a channel
+	player     *dcclib.Animator
+	lastUpdate time.Time
+	loaded     chan loadedTexture
 }
 
 // Dispose cleans viewers state
@@ -144,9 +164,68 @@ func (s *widgetState) Decode(data []byte) {
 	}
 
 	s.playMode = animationPlayMode(playMode)
+}
+
+// update receives the textures loaded since the last call, and advances the
+// player to the given time. The controls are synced with the player both ways,
+// so moving the sliders moves the player.
+func (s *widgetState) update(now time.Time, direction int) {
+	s.receiveTextures()
+
+	if s.player == nil {
+		return
+	}
+
+	s.player.SetRepeat(s.repeat)
+	s.player.SetFrameDuration(time.Duration(s.tickTime) * time.Millisecond)
+
+	if mode := s.playMode.toPlayMode(); mode != s.player.PlayMode() {
+		s.player.SetPlayMode(mode)
+	}
+
+	if err := s.player.SetDirection(direction); err != nil {
+		log.Print(err)
+	}
+
+	if frame := int(s.controls.frame); frame != s.player.Frame() {
+		if err := s.player.SetFrame(frame); err != nil {
+			log.Print(err)
+		}
+	}
 
-	// update ticker
-	s.ticker.Reset(time.Second * time.Duration(s.tickTime) / miliseconds)
+	switch {
+	case s.isPlaying && !s.player.IsPlaying():
+		s.player.Play()
+	case !s.isPlaying && s.player.IsPlaying():
+		s.player.Pause()
+	}
+
+	if !s.lastUpdate.IsZero() {
+		s.player.Update(now.Sub(s.lastUpdate))
+	}
+
+	s.lastUpdate = now
+
+	s.controls.frame = int32(s.player.Frame())
+	s.isPlaying = s.player.IsPlaying()
+}
+
+// receiveTextures stores the textures the loader has finished, without waiting for the others
+func (s *widgetState) receiveTextures() {
+	for {
+		select {
+		case t := <-s.loaded:
+			if t.index >= len(s.textures) {
+				textures := make([]*giu.Texture, t.index+1)
+				copy(textures, s.textures)
+				s.textures = textures
+			}
+
+			s.textures[t.index] = t.texture
+		default:
+			return
+		}
+	}
 }
 
 func (p *widget) getStateID() string {
@@ -175,18 +254,18 @@ func (p *widget) initState() {
 		repeat:    false,
 		tickTime:  defaultTickTime,
 		playMode:  playModeForward,
+		player:    dcclib.NewAnimator(p.dcc),
 	}
 
-	state.ticker = time.NewTicker(time.Second * time.Duration(state.tickTime) / miliseconds)
-
 	p.setState(state)
 
-	go p.runPlayer(state)
-
 	numDirections := len(p.dcc.Directions())
 	numFrames := len(p.dcc.Direction(0).Frames())
 	totalFrames := numDirections * numFrames
 	state.images = make([]*image.RGBA, totalFrames)
+	state.textures = make([]*giu.Texture, totalFrames)
+	// buffered for every frame, so the loader never blocks on the render thread
+	state.loaded = make(chan loadedTexture, totalFrames)
 
 	directions := p.dcc.Directions()
 	for dirIdx, direction := range directions {
@@ -216,20 +295,14 @@ func (p *widget) initState() {
 		}
 	}
 
-	go func() {
-		textures := make([]*giu.Texture, totalFrames)
-
-		for frameIndex := 0; frameIndex < totalFrames; frameIndex++ {
-			frameIndex := frameIndex
-			p.textureLoader.CreateTextureFromARGB(state.images[frameIndex], func(t *giu.Texture) {
-				textures[frameIndex] = t
-			})
-		}
+	loaded := state.loaded
 
-		s := p.getState()
-		s.textures = textures
-		p.setState(s)
-	}()
+	for frameIndex := 0; frameIndex < totalFrames; frameIndex++ {
+		frameIndex := frameIndex
+		p.textureLoader.CreateTextureFromARGB(state.images[frameIndex], func(t *giu.Texture) {
+			loaded <- loadedTexture{index: frameIndex, texture: t}
+		})
+	}
 }
 
 func (p *widget) setState(s giu.Disposable) {
@@ -263,53 +336,3 @@ func (p *widget) makeImagePixel(val uint32) color.RGBA {
 
 	return RGBAColor
 }
-
-// wrap integer to max: wrap(450, 360) == 90
-func wrap(x, max int) int {
-	wrapped := x % max
-
-	if wrapped < 0 {
-		return max + wrapped
-	}
-
-	return wrapped
-}
-
-func (p *widget) runPlayer(state *widgetState) {
-	for range state.ticker.C {
-		if !state.isPlaying {
-			continue
-		}
-
-		numFrames := len(p.dcc.Direction(0).Frames())
-		isLastFrame := state.controls.frame == int32(numFrames-1)
-
-		// update play direction
-		switch state.playMode {
-		case playModeForward:
-			state.isForward = true
-		case playModeBackword:
-			state.isForward = false
-		case playModePingPong:
-			if isLastFrame || state.controls.frame == 0 {
-				state.isForward = !state.isForward
-			}
-		}
-
-		// now update the frame number
-		if state.isForward {
-			state.controls.frame++
-		} else {
-			state.controls.frame--
-		}
-
-		state.controls.frame = int32(wrap(int(state.controls.frame), numFrames))
-
-		// next, check for stopping/repeat
-		isStoppingFrame := (state.controls.frame == 0) || (state.controls.frame == int32(numFrames-1))
-
-		if isStoppingFrame && !state.repeat {
-			state.isPlaying = false
-		}
-	}
-}
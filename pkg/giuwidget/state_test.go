@@ -0,0 +1,177 @@
+package giuwidget
+
+import (
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AllenDang/giu"
+
+	dcclib "github.com/OpenDiablo2/dcc/pkg"
+)
+
+const (
+	testFrames    = 4
+	testTickTime  = 100
+	testTickDelay = testTickTime * time.Millisecond
+)
+
+// newTestState returns the state of a widget showing a dcc with two directions
+// of four frames, before any texture is loaded
+func newTestState(t *testing.T) *widgetState {
+	t.Helper()
+
+	d := dcclib.New()
+
+	for dir := 0; dir < 2; dir++ {
+		if err := d.AddDirection(&dcclib.Direction{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for frame := 0; frame < testFrames; frame++ {
+		img := image.NewPaletted(image.Rect(0, -4, 4, 0), nil)
+		img.Pix[0] = uint8(frame + 1)
+
+		if err := d.Direction(0).InsertFrame(frame, dcclib.NewFrame(img)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &widgetState{
+		tickTime: testTickTime,
+		playMode: playModeForward,
+		player:   dcclib.NewAnimator(d),
+		textures: make([]*giu.Texture, 2*testFrames),
+		loaded:   make(chan loadedTexture, 2*testFrames),
+	}
+}
+
+func TestUpdateStepsFrames(t *testing.T) {
+	s := newTestState(t)
+	s.isPlaying = true
+
+	now := time.Unix(0, 0)
+
+	// the first update only starts the clock
+	s.update(now, 0)
+
+	if s.controls.frame != 0 {
+		t.Fatalf("expected frame 0, got %d", s.controls.frame)
+	}
+
+	now = now.Add(2*testTickDelay + testTickDelay/2)
+	s.update(now, 0)
+
+	if s.controls.frame != 2 {
+		t.Fatalf("expected frame 2, got %d", s.controls.frame)
+	}
+
+	// the rest of the frame time carries over to the next update
+	now = now.Add(testTickDelay / 2)
+	s.update(now, 0)
+
+	if s.controls.frame != 3 {
+		t.Fatalf("expected frame 3, got %d", s.controls.frame)
+	}
+
+	// moving the slider while paused moves the player
+	s.isPlaying = false
+	s.controls.frame = 1
+	now = now.Add(10 * testTickDelay)
+	s.update(now, 0)
+
+	if s.controls.frame != 1 || s.player.Frame() != 1 {
+		t.Fatalf("expected frame 1, got %d and the player %d", s.controls.frame, s.player.Frame())
+	}
+
+	s.playMode = playModeBackword
+	s.isPlaying, s.repeat = true, true
+	now = now.Add(2 * testTickDelay)
+	s.update(now, 0)
+
+	if s.controls.frame != 3 {
+		t.Fatalf("playing backwards, expected frame 3, got %d", s.controls.frame)
+	}
+}
+
+func TestUpdateRepeatAndStop(t *testing.T) {
+	for _, repeat := range []bool{false, true} {
+		s := newTestState(t)
+		s.isPlaying, s.repeat = true, repeat
+
+		now := time.Unix(0, 0)
+		s.update(now, 0)
+
+		now = now.Add((testFrames + 1) * testTickDelay)
+		s.update(now, 0)
+
+		if repeat {
+			if !s.isPlaying || s.controls.frame != 1 {
+				t.Fatalf("repeating, expected to play frame 1, got frame %d, playing %v", s.controls.frame, s.isPlaying)
+			}
+
+			continue
+		}
+
+		if s.isPlaying || s.controls.frame != testFrames-1 {
+			t.Fatalf("expected to stop on the last frame, got frame %d, playing %v", s.controls.frame, s.isPlaying)
+		}
+
+		// the frame stays put once stopped
+		now = now.Add(testFrames * testTickDelay)
+		s.update(now, 0)
+
+		if s.controls.frame != testFrames-1 {
+			t.Fatalf("expected to stay on the last frame, got %d", s.controls.frame)
+		}
+	}
+}
+
+func TestUpdateReceivesTextures(t *testing.T) {
+	s := newTestState(t)
+	textures := make([]*giu.Texture, 2*testFrames)
+
+	// the loader calls back from its own goroutine
+	wg := &sync.WaitGroup{}
+
+	for idx := range textures {
+		textures[idx] = &giu.Texture{}
+
+		if idx%2 == 1 {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			s.loaded <- loadedTexture{index: idx, texture: textures[idx]}
+		}(idx)
+	}
+
+	wg.Wait()
+	s.update(time.Unix(0, 0), 0)
+
+	for idx := range textures {
+		want := textures[idx]
+		if idx%2 == 1 {
+			want = nil
+		}
+
+		if s.textures[idx] != want {
+			t.Fatalf("texture %d was not handed over", idx)
+		}
+	}
+
+	// a texture past the end grows the cache instead of panicking
+	extra := &giu.Texture{}
+	s.loaded <- loadedTexture{index: 2*testFrames + 1, texture: extra}
+	s.update(time.Unix(1, 0), 0)
+
+	if len(s.textures) != 2*testFrames+2 || s.textures[2*testFrames+1] != extra {
+		t.Fatal("expected the texture cache to grow")
+	}
+}
@@ -37,7 +37,9 @@ type TextureLoader interface {
 type textureLoader struct {
 	canLoadTextures bool
 	mutex           *sync.Mutex
+	resumed         *sync.Cond // signalled when canLoadTextures becomes true
 	loadQueue       *goconcurrentqueue.FIFO
+	processing      sync.Once
 }
 
 // NewTextureLoader creates a new texture loader
@@ -45,6 +47,7 @@ func NewTextureLoader() TextureLoader {
 	result := &textureLoader{}
 	result.canLoadTextures = false
 	result.mutex = &sync.Mutex{}
+	result.resumed = sync.NewCond(result.mutex)
 	result.loadQueue = goconcurrentqueue.NewFIFO()
 
 	return result
@@ -62,40 +65,47 @@ func (t *textureLoader) ResumeLoadingTextures() {
 	t.mutex.Lock()
 	t.canLoadTextures = true
 	t.mutex.Unlock()
+
+	t.resumed.Broadcast()
 }
 
-// ProcessTextureLoadRequests proceses texture loading request
+// ProcessTextureLoadRequests proceses texture loading request. The requests are
+// processed by a single goroutine, which is only started by the first call.
 func (t *textureLoader) ProcessTextureLoadRequests() {
-	go func() {
-		for {
-			item, err := t.loadQueue.DequeueOrWaitForNextElement()
-			if err != nil {
-				break
-			}
+	t.processing.Do(func() {
+		go t.processTextureLoadRequests()
+	})
+}
 
-			for {
-				t.mutex.Lock()
+func (t *textureLoader) processTextureLoadRequests() {
+	for {
+		item, err := t.loadQueue.DequeueOrWaitForNextElement()
+		if err != nil {
+			break
+		}
 
-				if !t.canLoadTextures {
-					t.mutex.Unlock()
-					continue
-				}
-				t.mutex.Unlock()
+		t.waitUntilResumed()
 
-				break
-			}
+		loadRequest := item.(TextureLoadRequestItem)
 
-			loadRequest := item.(TextureLoadRequestItem)
+		var texture *giu.Texture
 
-			var texture *giu.Texture
+		if texture, err = giu.NewTextureFromRgba(loadRequest.rgb); err != nil {
+			log.Fatal(err)
+		}
 
-			if texture, err = giu.NewTextureFromRgba(loadRequest.rgb); err != nil {
-				log.Fatal(err)
-			}
+		loadRequest.callback(texture)
+	}
+}
 
-			loadRequest.callback(texture)
-		}
-	}()
+// waitUntilResumed blocks while loading textures is stopped
+func (t *textureLoader) waitUntilResumed() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for !t.canLoadTextures {
+		t.resumed.Wait()
+	}
 }
 
 // CreateTextureFromARGB creates a texture fromo color given
@@ -0,0 +1,30 @@
+package giuwidget
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitUntilResumed(t *testing.T) {
+	loader, _ := NewTextureLoader().(*textureLoader)
+	resumed := make(chan struct{})
+
+	go func() {
+		loader.waitUntilResumed()
+		close(resumed)
+	}()
+
+	select {
+	case <-resumed:
+		t.Fatal("expected to wait while loading is stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	loader.ResumeLoadingTextures()
+
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("expected to stop waiting once loading is resumed")
+	}
+}
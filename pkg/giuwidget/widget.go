@@ -3,6 +3,7 @@ package giuwidget
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/AllenDang/giu"
 	"github.com/AllenDang/imgui-go"
@@ -44,8 +45,16 @@ func (p *widget) Build() {
 
 	viewerState := p.getState()
 
-	imageScale := uint32(viewerState.controls.scale)
 	dirIdx := dirLookup(int(viewerState.controls.direction), len(p.dcc.Directions()))
+
+	viewerState.update(time.Now(), dirIdx)
+
+	// keep rendering while playing, otherwise the next frame waits for user input
+	if viewerState.isPlaying {
+		giu.Update()
+	}
+
+	imageScale := uint32(viewerState.controls.scale)
 	frameIdx := viewerState.controls.frame
 
 	textureIdx := dirIdx*len(p.dcc.Direction(dirIdx).Frames()) + int(frameIdx)
@@ -61,7 +70,7 @@ func (p *widget) Build() {
 
 	var frameImage *giu.ImageWidget
 
-	if viewerState.textures == nil || len(viewerState.textures) <= int(frameIdx) || viewerState.textures[frameIdx] == nil {
+	if viewerState.textures == nil || len(viewerState.textures) <= textureIdx || viewerState.textures[textureIdx] == nil {
 		frameImage = giu.Image(nil).Size(imageW, imageH)
 	} else {
 		bw := p.dcc.Direction(dirIdx).Box.Dx()
@@ -98,31 +107,13 @@ func (p *widget) Build() {
 	}.Build()
 }
 
+// dirLookup maps the position of the direction slider to a direction index,
+// so that moving the slider rotates the animation clockwise
 func dirLookup(dir, numDirs int) int {
-	const (
-		_ = 1 << iota
-		_
-		four
-		eight
-		sixteen
-	)
-
-	d4 := []int{0, 1, 2, 3}
-	d8 := []int{0, 5, 1, 6, 2, 7, 3, 4}
-	d16 := []int{0, 9, 5, 10, 1, 11, 6, 12, 2, 13, 7, 14, 3, 15, 4, 8}
-
-	lookup := []int{0}
-
-	switch numDirs {
-	case four:
-		lookup = d4
-	case eight:
-		lookup = d8
-	case sixteen:
-		lookup = d16
-	default:
-		dir = 0
+	order, err := dcclib.DirectionOrder(numDirs)
+	if err != nil || dir < 0 || dir >= len(order) {
+		return 0
 	}
 
-	return lookup[dir]
+	return order[dir]
 }
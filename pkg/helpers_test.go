@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"image"
+	"testing"
+)
+
+// newTestDCC returns a DCC with one direction for every list of frame images
+func newTestDCC(t *testing.T, directions ...[]*image.Paletted) *DCC {
+	t.Helper()
+
+	d := New()
+
+	for _, images := range directions {
+		dir := &Direction{}
+
+		for _, img := range images {
+			dir.frames = append(dir.frames, NewFrame(img))
+		}
+
+		if err := d.AddDirection(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+// filledImage returns an image with the given bounds where every pixel is idx
+func filledImage(r image.Rectangle, idx uint8) *image.Paletted {
+	img := image.NewPaletted(r, nil)
+
+	for i := range img.Pix {
+		img.Pix[i] = idx
+	}
+
+	return img
+}
@@ -7,18 +7,28 @@ import (
 
 var _ image.PalettedImage = &Frame{}
 
+// ColorIndexAt returns the palette index of the pixel at x, y. The coordinates
+// are those of Frame.Box, relative to the anchor of the frame at 0, 0, so the
+// top left pixel of the frame is at Box.Min and not at 0, 0. Pixels outside of
+// the frame box are transparent (index 0).
+//
+// PixelData is laid out over the direction box while the frame is part of a
+// direction, and over the frame box otherwise, see layout; the row stride is
+// the width of that box, not Frame.Width.
 func (f *Frame) ColorIndexAt(x, y int) uint8 {
-	delta := image.Point{}
-	delta = delta.Sub(f.Box.Min)
+	box := f.layout()
 
-	pixelIndex := (delta.X + x) + ((delta.Y + y) * f.Width)
+	if !(image.Point{X: x, Y: y}).In(f.Box) {
+		return 0
+	}
+
+	pixelIndex := (x - box.Min.X) + ((y - box.Min.Y) * box.Dx())
 
-	if pixelIndex >= len(f.PixelData) {
-		pixelIndex = 0
+	if pixelIndex < 0 || pixelIndex >= len(f.PixelData) {
+		return 0
 	}
 
 	return f.PixelData[pixelIndex]
-	// return f.direction.PaletteEntries[f.PixelData[pixelIndex]]
 }
 
 func (f *Frame) ColorModel() color.Model {
@@ -29,9 +39,31 @@ func (f *Frame) Bounds() image.Rectangle {
 	return f.Box
 }
 
+// At returns the color of the pixel at x, y. Palette index 0 is transparent.
 func (f *Frame) At(x, y int) color.Color {
-	p := *f.direction.dcc.palette
-	c := p[f.ColorIndexAt(x, y)]
+	idx := f.ColorIndexAt(x, y)
+	if idx == 0 {
+		return color.RGBA{}
+	}
+
+	return f.palette()[idx]
+}
+
+// layout returns the rectangle that the pixel data is laid out over, this is
+// the direction box, or the frame box if the frame is not part of a direction
+func (f *Frame) layout() image.Rectangle {
+	if f.direction == nil || f.direction.Box == nil {
+		return f.Box
+	}
+
+	return *f.direction.Box
+}
+
+// palette returns the palette of the DCC that the frame belongs to
+func (f *Frame) palette() color.Palette {
+	if f.direction == nil || f.direction.dcc == nil || f.direction.dcc.palette == nil {
+		return *DefaultPalette()
+	}
 
-	return c
+	return *f.direction.dcc.palette
 }
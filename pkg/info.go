@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"fmt"
+	"image"
+)
+
+// Info is a summary of everything known about a decoded DCC file, laid out
+// for printing or encoding as JSON.
+type Info struct {
+	Version            byte            `json:"version"`
+	TotalSizeCoded     uint32          `json:"totalSizeCoded"`
+	NumberOfDirections int             `json:"numberOfDirections"`
+	FramesPerDirection int             `json:"framesPerDirection"`
+	DirectionOffsets   []uint32        `json:"directionOffsets"`
+	Directions         []DirectionInfo `json:"directions"`
+}
+
+// DirectionInfo is a summary of a single direction
+type DirectionInfo struct {
+	OutSizeCoded     int              `json:"outSizeCoded"`
+	CompressionFlags int              `json:"compressionFlags"`
+	BitWidths        BitWidthInfo     `json:"bitWidths"`
+	Substreams       SubstreamInfo    `json:"substreamSizes"`
+	Box              RectangleInfo    `json:"box"`
+	PaletteEntries   []int            `json:"paletteEntries"`
+	Frames           []FrameInfo      `json:"frames"`
+	Stats            CompressionStats `json:"compressionStats"`
+}
+
+// BitWidthInfo holds the number of bits used by each frame header field
+// of a direction
+type BitWidthInfo struct {
+	Variable0    int `json:"variable0"`
+	Width        int `json:"width"`
+	Height       int `json:"height"`
+	XOffset      int `json:"xOffset"`
+	YOffset      int `json:"yOffset"`
+	OptionalData int `json:"optionalData"`
+	CodedBytes   int `json:"codedBytes"`
+}
+
+// SubstreamInfo holds the sizes, in bits, of the substreams of a direction
+type SubstreamInfo struct {
+	EqualCells    uint32 `json:"equalCells"`
+	PixelMask     uint32 `json:"pixelMask"`
+	EncodingType  uint32 `json:"encodingType"`
+	RawPixelCodes uint32 `json:"rawPixelCodes"`
+}
+
+// RectangleInfo is an image.Rectangle, flattened
+type RectangleInfo struct {
+	MinX int `json:"minX"`
+	MinY int `json:"minY"`
+	MaxX int `json:"maxX"`
+	MaxY int `json:"maxY"`
+}
+
+// FrameInfo is a summary of a single frame
+type FrameInfo struct {
+	Width              int  `json:"width"`
+	Height             int  `json:"height"`
+	XOffset            int  `json:"xOffset"`
+	YOffset            int  `json:"yOffset"`
+	NumberOfCodedBytes int  `json:"numberOfCodedBytes"`
+	FrameIsBottomUp    bool `json:"frameIsBottomUp"`
+}
+
+// Info returns a summary of the DCC
+func (d *DCC) Info() *Info {
+	info := &Info{
+		Version:            d.Version,
+		TotalSizeCoded:     d.TotalSizeCoded,
+		NumberOfDirections: len(d.directions),
+		FramesPerDirection: int(d.framesPerDirection),
+		DirectionOffsets:   append([]uint32{}, d.DirectionOffsets...),
+		Directions:         make([]DirectionInfo, len(d.directions)),
+	}
+
+	for idx, dir := range d.directions {
+		info.Directions[idx] = dir.info()
+	}
+
+	return info
+}
+
+func (d *Direction) info() DirectionInfo {
+	info := DirectionInfo{
+		OutSizeCoded:     d.OutSizeCoded,
+		CompressionFlags: d.CompressionFlags,
+		BitWidths: BitWidthInfo{
+			Variable0:    d.Variable0Bits,
+			Width:        d.WidthBits,
+			Height:       d.HeightBits,
+			XOffset:      d.XOffsetBits,
+			YOffset:      d.YOffsetBits,
+			OptionalData: d.OptionalDataBits,
+			CodedBytes:   d.CodedBytesBits,
+		},
+		Substreams: SubstreamInfo{
+			EqualCells:    d.EqualCellsBitstreamSize,
+			PixelMask:     d.PixelMaskBitstreamSize,
+			EncodingType:  d.EncodingTypeBitstreamSize,
+			RawPixelCodes: d.RawPixelCodesBitstreamSize,
+		},
+		PaletteEntries: make([]int, d.PaletteEntryCount),
+		Frames:         make([]FrameInfo, len(d.frames)),
+		Stats:          d.Stats,
+	}
+
+	if d.Box != nil {
+		info.Box = rectangleInfo(*d.Box)
+	}
+
+	for idx := range info.PaletteEntries {
+		info.PaletteEntries[idx] = int(d.PaletteEntries[idx])
+	}
+
+	for idx, frame := range d.frames {
+		info.Frames[idx] = FrameInfo{
+			Width:              frame.Width,
+			Height:             frame.Height,
+			XOffset:            frame.XOffset,
+			YOffset:            frame.YOffset,
+			NumberOfCodedBytes: frame.NumberOfCodedBytes,
+			FrameIsBottomUp:    frame.FrameIsBottomUp,
+		}
+	}
+
+	return info
+}
+
+func rectangleInfo(r image.Rectangle) RectangleInfo {
+	return RectangleInfo{MinX: r.Min.X, MinY: r.Min.Y, MaxX: r.Max.X, MaxY: r.Max.Y}
+}
+
+func (r RectangleInfo) String() string {
+	return fmt.Sprintf("(%d,%d)-(%d,%d)", r.MinX, r.MinY, r.MaxX, r.MaxY)
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"encoding/json"
+	"image"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// checkKeys checks that the JSON object has exactly the given keys, and returns it
+func checkKeys(t *testing.T, name string, v interface{}, keys ...string) map[string]interface{} {
+	t.Helper()
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s: expected an object, got %T", name, v)
+	}
+
+	got := make([]string, 0, len(obj))
+	for key := range obj {
+		got = append(got, key)
+	}
+
+	sort.Strings(got)
+	sort.Strings(keys)
+
+	if strings.Join(got, ",") != strings.Join(keys, ",") {
+		t.Fatalf("%s: expected the fields %v, got %v", name, keys, got)
+	}
+
+	return obj
+}
+
+func TestInfoJSON(t *testing.T) {
+	d := newTestDCC(t, []*image.Paletted{
+		filledImage(image.Rect(-3, -5, 4, 0), 3),
+		filledImage(image.Rect(-2, -6, 2, 1), 8),
+	})
+	decoded, _ := encodeDecode(t, d)
+
+	data, err := json.Marshal(decoded.Info())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	info := checkKeys(t, "info", v,
+		"version", "totalSizeCoded", "numberOfDirections", "framesPerDirection", "directionOffsets", "directions")
+
+	if info["numberOfDirections"] != 1.0 || info["framesPerDirection"] != 2.0 {
+		t.Fatalf("expected 1 direction of 2 frames, got %v and %v", info["numberOfDirections"], info["framesPerDirection"])
+	}
+
+	directions, _ := info["directions"].([]interface{})
+	if len(directions) != 1 {
+		t.Fatalf("expected 1 direction, got %v", info["directions"])
+	}
+
+	dir := checkKeys(t, "direction", directions[0],
+		"outSizeCoded", "compressionFlags", "bitWidths", "substreamSizes", "box",
+		"paletteEntries", "frames", "compressionStats")
+
+	checkKeys(t, "bit widths", dir["bitWidths"],
+		"variable0", "width", "height", "xOffset", "yOffset", "optionalData", "codedBytes")
+	checkKeys(t, "substream sizes", dir["substreamSizes"], "equalCells", "pixelMask", "encodingType", "rawPixelCodes")
+
+	box := checkKeys(t, "box", dir["box"], "minX", "minY", "maxX", "maxY")
+	if box["minX"] != -3.0 || box["minY"] != -6.0 || box["maxX"] != 4.0 || box["maxY"] != 1.0 {
+		t.Fatalf("expected the box (-3,-6)-(4,1), got %v", box)
+	}
+
+	// the frames leave parts of the direction box transparent
+	if entries, _ := dir["paletteEntries"].([]interface{}); len(entries) != 3 || entries[0] != 0.0 || entries[1] != 3.0 || entries[2] != 8.0 {
+		t.Fatalf("expected the palette entries [0 3 8], got %v", dir["paletteEntries"])
+	}
+
+	stats := checkKeys(t, "compression stats", dir["compressionStats"],
+		"cells", "equalCells", "pixelMaskPopCount", "rawCodedCells", "displacementCodedCells", "solidCells",
+		"oneBitCells", "twoBitCells", "streamBits", "paletteEntries", "rawSize")
+	checkKeys(t, "stream bits", stats["streamBits"],
+		"equalCells", "pixelMask", "encodingType", "rawPixelCodes", "pixelCodesAndDisplacement")
+
+	frames, _ := dir["frames"].([]interface{})
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %v", dir["frames"])
+	}
+
+	frame := checkKeys(t, "frame", frames[0],
+		"width", "height", "xOffset", "yOffset", "numberOfCodedBytes", "frameIsBottomUp")
+	if frame["width"] != 7.0 || frame["height"] != 5.0 || frame["xOffset"] != -3.0 || frame["yOffset"] != -1.0 {
+		t.Fatalf("expected a 7x5 frame at -3,-1, got %v", frame)
+	}
+}
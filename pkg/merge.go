@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+)
+
+// Errors returned when merging and splitting
+var (
+	ErrNothingToMerge         = errors.New("no dcc to merge")
+	ErrDirectionCountMismatch = errors.New("dcc has a different number of directions")
+	ErrBadFrameRange          = errors.New("bad frame range")
+	ErrNoDirectionsSelected   = errors.New("no directions selected")
+)
+
+// FrameRange selects the frames from Start up to, but not including, End
+type FrameRange struct {
+	Start, End int
+}
+
+// String formats the range with the last frame included, like 0-3
+func (r FrameRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End-1)
+}
+
+// empty returns a new dcc with the version and palette of d, but no directions
+func (d *DCC) empty() *DCC {
+	e := New()
+	e.Version = d.Version
+
+	if d.palette != nil {
+		e.SetPalette(color.Palette(*d.palette))
+	}
+
+	return e
+}
+
+// Concat returns a new dcc with the frames of every given dcc one after the
+// other, in each direction. The dccs must have the same number of directions.
+// The new dcc has the version and palette of the first one.
+func Concat(dccs ...*DCC) (*DCC, error) {
+	if len(dccs) == 0 {
+		return nil, ErrNothingToMerge
+	}
+
+	first := dccs[0]
+	merged := first.empty()
+
+	for idx, d := range dccs {
+		if len(d.directions) != len(first.directions) {
+			const fmtErr = "%w: dcc %d has %d, expecting %d"
+			return nil, fmt.Errorf(fmtErr, ErrDirectionCountMismatch, idx, len(d.directions), len(first.directions))
+		}
+	}
+
+	for dirIdx := range first.directions {
+		dir := &Direction{dcc: merged}
+
+		for _, d := range dccs {
+			for _, f := range d.directions[dirIdx].frames {
+				dir.frames = append(dir.frames, f.clone())
+			}
+		}
+
+		dir.edit(func() {})
+		merged.directions = append(merged.directions, dir)
+	}
+
+	merged.directionsChanged()
+
+	return merged, nil
+}
+
+// ExtractFrames returns a new dcc with copies of the frames in the range, in
+// every direction
+func (d *DCC) ExtractFrames(r FrameRange) (*DCC, error) {
+	if r.Start < 0 || r.End > int(d.framesPerDirection) || r.Start >= r.End {
+		const fmtErr = "%w: %s, the dcc has %d frames"
+		return nil, fmt.Errorf(fmtErr, ErrBadFrameRange, r, d.framesPerDirection)
+	}
+
+	extracted := d.empty()
+
+	for _, src := range d.directions {
+		dir := &Direction{dcc: extracted}
+
+		for _, f := range src.frames[r.Start:r.End] {
+			dir.frames = append(dir.frames, f.clone())
+		}
+
+		dir.edit(func() {})
+		extracted.directions = append(extracted.directions, dir)
+	}
+
+	extracted.directionsChanged()
+
+	return extracted, nil
+}
+
+// Split returns a new dcc for every frame range, see ExtractFrames
+func (d *DCC) Split(ranges ...FrameRange) ([]*DCC, error) {
+	parts := make([]*DCC, len(ranges))
+
+	for idx, r := range ranges {
+		part, err := d.ExtractFrames(r)
+		if err != nil {
+			return nil, err
+		}
+
+		parts[idx] = part
+	}
+
+	return parts, nil
+}
+
+// ExtractDirections returns a new dcc with copies of the given directions, in
+// the given order
+func (d *DCC) ExtractDirections(directions ...int) (*DCC, error) {
+	if len(directions) == 0 {
+		return nil, ErrNoDirectionsSelected
+	}
+
+	extracted := d.empty()
+
+	for _, n := range directions {
+		if n < 0 || n >= len(d.directions) {
+			return nil, fmt.Errorf("%w: %d", ErrDirectionOutOfRange, n)
+		}
+
+		if err := extracted.AddDirection(d.directions[n]); err != nil {
+			return nil, err
+		}
+	}
+
+	return extracted, nil
+}
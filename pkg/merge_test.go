@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+// regionDCC returns a DCC with frames that encode without loss, the seed
+// gives every frame its own colors
+func regionDCC(t *testing.T, numDirections, numFrames, seed int) (*DCC, [][]*image.Paletted) {
+	t.Helper()
+
+	boxes := []image.Rectangle{
+		image.Rect(-32, -48, -11, 5),
+		image.Rect(-16, -64, 19, -30),
+		image.Rect(0, -32, 37, 1),
+	}
+
+	directions := make([][]*image.Paletted, numDirections)
+
+	for dir := range directions {
+		for frame := 0; frame < numFrames; frame++ {
+			directions[dir] = append(directions[dir], regionImage(boxes[(dir+frame)%len(boxes)], seed+dir*numFrames+frame))
+		}
+	}
+
+	return newTestDCC(t, directions...), directions
+}
+
+// checkFrames encodes and decodes the DCC, and compares the frames of the result
+func checkFrames(t *testing.T, name string, d *DCC, want [][]*image.Paletted) {
+	t.Helper()
+
+	decoded, _ := encodeDecode(t, d)
+
+	if len(decoded.Directions()) != len(want) {
+		t.Fatalf("%s: expected %d directions, got %d", name, len(want), len(decoded.Directions()))
+	}
+
+	for dir, frames := range want {
+		if got := len(decoded.Direction(dir).Frames()); got != len(frames) {
+			t.Fatalf("%s: direction %d, expected %d frames, got %d", name, dir, len(frames), got)
+		}
+
+		for frame, img := range frames {
+			checkFrame(t, name, decoded.Direction(dir).Frame(frame), img)
+		}
+	}
+}
+
+func TestConcatAndSplit(t *testing.T) {
+	first, firstFrames := regionDCC(t, 2, 2, 0)
+	second, secondFrames := regionDCC(t, 2, 1, 10)
+
+	merged, err := Concat(first, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mergedFrames := make([][]*image.Paletted, 2)
+	for dir := range mergedFrames {
+		mergedFrames[dir] = append(append(mergedFrames[dir], firstFrames[dir]...), secondFrames[dir]...)
+	}
+
+	checkFrames(t, "concat", merged, mergedFrames)
+
+	parts, err := merged.Split(FrameRange{0, 2}, FrameRange{2, 3}, FrameRange{1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFrames(t, "first part", parts[0], firstFrames)
+	checkFrames(t, "second part", parts[1], secondFrames)
+	checkFrames(t, "overlapping part", parts[2], [][]*image.Paletted{mergedFrames[0][1:], mergedFrames[1][1:]})
+}
+
+func TestExtractDirections(t *testing.T) {
+	d, frames := regionDCC(t, 4, 2, 0)
+
+	extracted, err := d.ExtractDirections(3, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFrames(t, "extract", extracted, [][]*image.Paletted{frames[3], frames[1], frames[3]})
+
+	if _, err := d.ExtractDirections(); !errors.Is(err, ErrNoDirectionsSelected) {
+		t.Fatalf("expected %v, got %v", ErrNoDirectionsSelected, err)
+	}
+
+	if _, err := d.ExtractDirections(0, 4); !errors.Is(err, ErrDirectionOutOfRange) {
+		t.Fatalf("expected %v, got %v", ErrDirectionOutOfRange, err)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	d, _ := regionDCC(t, 2, 3, 0)
+	other, _ := regionDCC(t, 4, 3, 0)
+
+	if _, err := Concat(d, other); !errors.Is(err, ErrDirectionCountMismatch) {
+		t.Fatalf("expected %v, got %v", ErrDirectionCountMismatch, err)
+	}
+
+	if _, err := Concat(); !errors.Is(err, ErrNothingToMerge) {
+		t.Fatalf("expected %v, got %v", ErrNothingToMerge, err)
+	}
+
+	for _, r := range []FrameRange{{-1, 2}, {0, 4}, {2, 2}, {2, 1}} {
+		if _, err := d.ExtractFrames(r); !errors.Is(err, ErrBadFrameRange) {
+			t.Fatalf("%s: expected %v, got %v", r, ErrBadFrameRange, err)
+		}
+
+		if _, err := d.Split(FrameRange{0, 1}, r); !errors.Is(err, ErrBadFrameRange) {
+			t.Fatalf("%s: expected %v, got %v", r, ErrBadFrameRange, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// ErrNoMirror is returned when a direction faces straight north or south, so
+// it can not be made from another direction
+var ErrNoMirror = errors.New("direction is its own mirror")
+
+// FlippedImage returns a horizontally flipped copy of the frame as a paletted
+// image. The frame is flipped around the anchor, so a pixel at x lands at -1-x
+// and the image is as far left of the anchor as the frame was right of it.
+func (f *Frame) FlippedImage() *image.Paletted {
+	src := f.Paletted()
+	r := src.Bounds()
+	dst := image.NewPaletted(image.Rect(-r.Max.X, r.Min.Y, -r.Min.X, r.Max.Y), src.Palette)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.SetColorIndex(-1-x, y, src.ColorIndexAt(x, y))
+		}
+	}
+
+	return dst
+}
+
+// FlipHorizontal flips the frame around the anchor, see FlippedImage
+func (f *Frame) FlipHorizontal() {
+	f.SetPixels(f.FlippedImage())
+}
+
+// FlipHorizontal flips every frame of the direction around the anchor, see Frame.FlippedImage
+func (d *Direction) FlipHorizontal() {
+	flipped := make([]*image.Paletted, len(d.frames))
+
+	for idx, f := range d.frames {
+		flipped[idx] = f.FlippedImage()
+	}
+
+	d.edit(func() {
+		for idx, f := range d.frames {
+			f.setPixels(flipped[idx])
+		}
+	})
+}
+
+// MirrorDirection replaces direction n with a flipped copy of the direction that
+// mirrors it, see MirrorDirection. It returns the index of that direction.
+func (d *DCC) MirrorDirection(n int) (int, error) {
+	from, err := MirrorDirection(n, len(d.directions))
+	if err != nil {
+		return 0, err
+	}
+
+	if from == n {
+		return 0, fmt.Errorf("%w: %d", ErrNoMirror, n)
+	}
+
+	mirrored := d.directions[from].clone(d)
+	mirrored.FlipHorizontal()
+
+	d.directions[n] = mirrored
+	d.directionsChanged()
+
+	return from, nil
+}
+
+// MirrorMissingDirections fills every direction that has no opaque pixels with
+// a flipped copy of the direction that mirrors it, if that one has any. It
+// returns the indices of the directions that were filled in.
+func (d *DCC) MirrorMissingDirections() ([]int, error) {
+	missing := make([]bool, len(d.directions))
+
+	for idx, dir := range d.directions {
+		missing[idx] = dir.OpaqueBounds().Empty()
+	}
+
+	filled := make([]int, 0)
+
+	for idx := range d.directions {
+		if !missing[idx] {
+			continue
+		}
+
+		from, err := MirrorDirection(idx, len(d.directions))
+		if err != nil {
+			return filled, err
+		}
+
+		if from == idx || missing[from] {
+			continue
+		}
+
+		if _, err := d.MirrorDirection(idx); err != nil {
+			return filled, err
+		}
+
+		filled = append(filled, idx)
+	}
+
+	return filled, nil
+}
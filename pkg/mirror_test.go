@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+// numberedImage returns an image where every pixel has its own color
+func numberedImage(r image.Rectangle) *image.Paletted {
+	img := image.NewPaletted(r, nil)
+
+	for idx := range img.Pix {
+		img.Pix[idx] = uint8(1 + idx%255)
+	}
+
+	return img
+}
+
+func checkFlipped(t *testing.T, src, flipped *image.Paletted) {
+	t.Helper()
+
+	if want := image.Rect(-src.Rect.Max.X, src.Rect.Min.Y, -src.Rect.Min.X, src.Rect.Max.Y); flipped.Rect != want {
+		t.Fatalf("expected the flipped box %v, got %v", want, flipped.Rect)
+	}
+
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			if flipped.ColorIndexAt(-1-x, y) != src.ColorIndexAt(x, y) {
+				t.Fatalf("expected (%d,%d) to land on (%d,%d)", x, y, -1-x, y)
+			}
+		}
+	}
+}
+
+func TestFlippedImage(t *testing.T) {
+	src := numberedImage(image.Rect(-3, -5, 7, 0))
+	f := newTestDCC(t, []*image.Paletted{src}).Direction(0).Frame(0)
+
+	flipped := f.FlippedImage()
+	if flipped.Rect != image.Rect(-7, -5, 3, 0) {
+		t.Fatalf("expected the box (-7,-5)-(3,0), got %v", flipped.Rect)
+	}
+
+	checkFlipped(t, src, flipped)
+
+	f.FlipHorizontal()
+	f.FlipHorizontal()
+
+	if got := f.Paletted(); got.Rect != src.Rect || !reflect.DeepEqual(got.Pix, src.Pix) {
+		t.Fatal("expected flipping twice to give the frame back")
+	}
+}
+
+func TestMirrorMissingDirections(t *testing.T) {
+	const numFrames = 2
+
+	empty := image.NewPaletted(image.Rect(-1, -1, 1, 0), nil)
+	frames := func(drawn bool, r image.Rectangle) []*image.Paletted {
+		result := make([]*image.Paletted, numFrames)
+		for idx := range result {
+			result[idx] = empty
+
+			if drawn {
+				result[idx] = numberedImage(r.Add(image.Pt(idx, 0)))
+			}
+		}
+
+		return result
+	}
+
+	// south west is drawn and south east is missing, north west and north east
+	// are both drawn. West and east are both missing, south can not be mirrored.
+	d := newTestDCC(t,
+		frames(true, image.Rect(-3, -5, 7, 0)), // south west
+		frames(true, image.Rect(-2, -4, 1, 0)), // north west
+		frames(true, image.Rect(-6, -2, 2, 0)), // north east
+		frames(false, image.Rectangle{}),       // south east
+		frames(false, image.Rectangle{}),       // south
+		frames(false, image.Rectangle{}),       // west
+		frames(true, image.Rect(-1, -9, 1, 0)), // north
+		frames(false, image.Rectangle{}),       // east
+	)
+
+	northEast := d.Direction(testNorthEast).Frame(0).Paletted()
+
+	filled, err := d.MirrorMissingDirections()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(filled, []int{testSouthEast}) {
+		t.Fatalf("expected only south east to be filled in, got %v", filled)
+	}
+
+	for frame := 0; frame < numFrames; frame++ {
+		checkFlipped(t,
+			d.Direction(testSouthWest).Frame(frame).Paletted(),
+			d.Direction(testSouthEast).Frame(frame).Paletted())
+	}
+
+	if got := d.Direction(testNorthEast).Frame(0).Paletted(); !reflect.DeepEqual(got, northEast) {
+		t.Fatal("expected the drawn north east direction to be left alone")
+	}
+
+	for _, dir := range []int{testSouth, testWest, testEast} {
+		if !d.Direction(dir).OpaqueBounds().Empty() {
+			t.Fatalf("expected direction %d to stay empty", dir)
+		}
+	}
+}
+
+func TestMirrorDirectionErrors(t *testing.T) {
+	directions := make([][]*image.Paletted, 8)
+	for idx := range directions {
+		directions[idx] = []*image.Paletted{numberedImage(image.Rect(-2, -2, 2, 0))}
+	}
+
+	d := newTestDCC(t, directions...)
+
+	for _, dir := range []int{testSouth, testNorth} {
+		if _, err := d.MirrorDirection(dir); !errors.Is(err, ErrNoMirror) {
+			t.Fatalf("direction %d, expected %v, got %v", dir, ErrNoMirror, err)
+		}
+	}
+
+	if _, err := d.MirrorDirection(8); !errors.Is(err, ErrDirectionOutOfRange) {
+		t.Fatalf("expected %v, got %v", ErrDirectionOutOfRange, err)
+	}
+
+	if from, err := d.MirrorDirection(testEast); err != nil || from != testWest {
+		t.Fatalf("expected east to be made from west, got %d, %v", from, err)
+	}
+}
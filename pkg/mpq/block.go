@@ -0,0 +1,162 @@
+package mpq
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+type blockFlag uint32
+
+// block flags
+const (
+	flagImplode    blockFlag = 0x00000100
+	flagCompress   blockFlag = 0x00000200
+	flagEncrypted  blockFlag = 0x00010000
+	flagFixKey     blockFlag = 0x00020000
+	flagSingleUnit blockFlag = 0x01000000
+	flagSectorCRC  blockFlag = 0x04000000
+	flagExists     blockFlag = 0x80000000
+)
+
+type blockEntry struct {
+	offset         uint32
+	compressedSize uint32
+	fileSize       uint32
+	flags          blockFlag
+}
+
+func (b *blockEntry) hasFlag(f blockFlag) bool {
+	return b.flags&f == f
+}
+
+func (b *blockEntry) compressed() bool {
+	return b.hasFlag(flagImplode) || b.hasFlag(flagCompress)
+}
+
+// readBlock reads, decrypts and decompresses the data of a file
+func (a *Archive) readBlock(name string, b *blockEntry) ([]byte, error) {
+	if int64(b.offset)+int64(b.compressedSize) > a.size-a.offset {
+		return nil, ErrFileTooLarge
+	}
+
+	raw := make([]byte, b.compressedSize)
+
+	if _, err := a.r.ReadAt(raw, a.offset+int64(b.offset)); err != nil {
+		return nil, err
+	}
+
+	var key uint32
+
+	if b.hasFlag(flagEncrypted) {
+		key = fileKey(name, b)
+	}
+
+	if b.hasFlag(flagSingleUnit) {
+		if b.hasFlag(flagEncrypted) {
+			decryptBytes(raw, key)
+		}
+
+		return a.decompressSector(b, raw, b.fileSize)
+	}
+
+	return a.readSectors(b, raw, key)
+}
+
+func (a *Archive) readSectors(b *blockEntry, raw []byte, key uint32) ([]byte, error) {
+	sectorSize := a.sectorSize()
+	numSectors := (b.fileSize + sectorSize - 1) / sectorSize
+	offsets := make([]uint32, numSectors+1)
+
+	if b.compressed() {
+		// compressed files start with a table of where each sector begins
+		numOffsets := numSectors + 1
+		if b.hasFlag(flagSectorCRC) {
+			numOffsets++
+		}
+
+		if uint32(len(raw)) < numOffsets*4 {
+			return nil, fmt.Errorf(errFmtCorruptArchive, ErrCorrupt, "sector table is truncated")
+		}
+
+		table := make([]uint32, numOffsets)
+
+		for i := range table {
+			table[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+
+		if b.hasFlag(flagEncrypted) {
+			decrypt(table, key-1)
+		}
+
+		copy(offsets, table)
+	} else {
+		for i := range offsets {
+			offsets[i] = uint32(i) * sectorSize
+		}
+
+		offsets[numSectors] = b.fileSize
+	}
+
+	data := make([]byte, 0, b.fileSize)
+
+	for i := uint32(0); i < numSectors; i++ {
+		start, end := offsets[i], offsets[i+1]
+
+		if start > end || end > uint32(len(raw)) {
+			return nil, fmt.Errorf(errFmtCorruptArchive, ErrCorrupt, "bad sector offset")
+		}
+
+		sector := append([]byte{}, raw[start:end]...)
+
+		if b.hasFlag(flagEncrypted) {
+			decryptBytes(sector, key+i)
+		}
+
+		expected := sectorSize
+		if remaining := b.fileSize - i*sectorSize; remaining < expected {
+			expected = remaining
+		}
+
+		sector, err := a.decompressSector(b, sector, expected)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, sector...)
+	}
+
+	return data, nil
+}
+
+// decompressSector decompresses a sector that is expected to hold the given
+// number of bytes, sectors that did not get smaller are stored uncompressed
+func (a *Archive) decompressSector(b *blockEntry, sector []byte, expected uint32) ([]byte, error) {
+	if !b.compressed() || uint32(len(sector)) >= expected {
+		if uint32(len(sector)) < expected {
+			return nil, fmt.Errorf(errFmtCorruptArchive, ErrCorrupt, "sector is truncated")
+		}
+
+		return sector[:expected], nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if b.hasFlag(flagImplode) {
+		data, err = explode(sector)
+	} else {
+		data, err = decompress(sector)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if uint32(len(data)) != expected {
+		return nil, fmt.Errorf("%w, sector is %d bytes, expected %d", ErrDecompress, len(data), expected)
+	}
+
+	return data, nil
+}
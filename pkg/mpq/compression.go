@@ -0,0 +1,74 @@
+package mpq
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+)
+
+type compressionType byte
+
+// the compression types of a sector, several can be combined
+const (
+	compressionHuffman     compressionType = 0x01
+	compressionZlib        compressionType = 0x02
+	compressionImplode     compressionType = 0x08
+	compressionBzip2       compressionType = 0x10
+	compressionADPCMMono   compressionType = 0x40
+	compressionADPCMStereo compressionType = 0x80
+)
+
+// decompress decompresses a sector that starts with a byte of compression types
+func decompress(sector []byte) ([]byte, error) {
+	if len(sector) < 1 {
+		return nil, fmt.Errorf("%w, empty sector", ErrDecompress)
+	}
+
+	mask, data := compressionType(sector[0]), sector[1:]
+
+	// the audio compressions are only used for sound files
+	if unsupported := mask &^ (compressionZlib | compressionImplode | compressionBzip2); unsupported != 0 {
+		return nil, fmt.Errorf("%w, 0x%02x", ErrCompression, byte(unsupported))
+	}
+
+	var err error
+
+	// the types are applied in the reverse order that they were compressed in
+	if mask&compressionBzip2 != 0 {
+		if data, err = ioutil.ReadAll(bzip2.NewReader(bytes.NewReader(data))); err != nil {
+			return nil, fmt.Errorf("%w, bzip2: %v", ErrDecompress, err)
+		}
+	}
+
+	if mask&compressionImplode != 0 {
+		if data, err = explode(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if mask&compressionZlib != 0 {
+		if data, err = inflate(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w, zlib: %v", ErrDecompress, err)
+	}
+
+	defer func() { _ = r.Close() }()
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w, zlib: %v", ErrDecompress, err)
+	}
+
+	return out, nil
+}
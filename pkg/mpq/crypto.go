@@ -0,0 +1,106 @@
+package mpq
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// hash types used with hashString
+const (
+	hashTableOffset uint32 = iota
+	hashNameA
+	hashNameB
+	hashFileKey
+)
+
+const (
+	cryptTableSize   = 0x500
+	cryptTableStride = 0x100
+	cryptSeedsPerRow = 5
+	decryptTableRow  = 0x400
+)
+
+var cryptTable = buildCryptTable() //nolint:gochecknoglobals // lookup table
+
+func buildCryptTable() (table [cryptTableSize]uint32) {
+	seed := uint32(0x00100001)
+
+	next := func() uint32 {
+		seed = (seed*125 + 3) % 0x2AAAAB
+		return seed
+	}
+
+	for i := 0; i < cryptTableStride; i++ {
+		for j, index := 0, i; j < cryptSeedsPerRow; j, index = j+1, index+cryptTableStride {
+			hi := (next() & 0xFFFF) << 16
+			lo := next() & 0xFFFF
+			table[index] = hi | lo
+		}
+	}
+
+	return table
+}
+
+// normalizeName converts a path to the form used inside of archives
+func normalizeName(name string) string {
+	return strings.ReplaceAll(name, "/", "\\")
+}
+
+// hashString hashes a file name, the hash is case-insensitive
+func hashString(s string, hashType uint32) uint32 {
+	seed1, seed2 := uint32(0x7FED7FED), uint32(0xEEEEEEEE)
+
+	for _, c := range []byte(strings.ToUpper(normalizeName(s))) {
+		ch := uint32(c)
+		seed1 = cryptTable[hashType*cryptTableStride+ch] ^ (seed1 + seed2)
+		seed2 = ch + seed1 + seed2 + (seed2 << 5) + 3
+	}
+
+	return seed1
+}
+
+// decrypt decrypts the words of data in place
+func decrypt(data []uint32, key uint32) {
+	seed := uint32(0xEEEEEEEE)
+
+	for i := range data {
+		seed += cryptTable[decryptTableRow+(key&0xFF)]
+		ch := data[i] ^ (key + seed)
+		key = ((^key << 0x15) + 0x11111111) | (key >> 0x0B)
+		seed = ch + seed + (seed << 5) + 3
+		data[i] = ch
+	}
+}
+
+// decryptBytes decrypts data in place, trailing bytes that do not make up a
+// whole word are not encrypted and are left as they are
+func decryptBytes(data []byte, key uint32) {
+	words := make([]uint32, len(data)/4)
+
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+
+	decrypt(words, key)
+
+	for i := range words {
+		binary.LittleEndian.PutUint32(data[i*4:], words[i])
+	}
+}
+
+// fileKey returns the encryption key of a file
+func fileKey(name string, b *blockEntry) uint32 {
+	name = normalizeName(name)
+
+	if idx := strings.LastIndex(name, "\\"); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	key := hashString(name, hashFileKey)
+
+	if b.hasFlag(flagFixKey) {
+		key = (key + b.offset) ^ b.fileSize
+	}
+
+	return key
+}
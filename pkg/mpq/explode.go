@@ -0,0 +1,213 @@
+package mpq
+
+import (
+	"fmt"
+)
+
+// explode decompresses data compressed with the PKWARE Data Compression
+// Library "implode" method, it is based on blast.c by Mark Adler
+
+const (
+	explodeMaxBits       = 13
+	explodeEndLength     = 519
+	explodeMinDictBits   = 4
+	explodeMaxDictBits   = 6
+	explodeShortDistBits = 2
+)
+
+//nolint:gochecknoglobals // compact code length tables, see huffman.construct
+var (
+	explodeLiteralLengths = []byte{
+		11, 124, 8, 7, 28, 7, 188, 13, 76, 4, 10, 8, 12, 10, 12, 10, 8, 23, 8,
+		9, 7, 6, 7, 8, 7, 6, 55, 8, 23, 24, 12, 11, 7, 9, 11, 12, 6, 7, 22, 5,
+		7, 24, 6, 11, 9, 6, 7, 22, 7, 11, 38, 7, 9, 8, 25, 11, 8, 11, 9, 12,
+		8, 12, 5, 38, 5, 38, 5, 11, 7, 5, 6, 21, 6, 10, 53, 8, 7, 24, 10, 27,
+		44, 253, 253, 253, 252, 252, 252, 13, 12, 45, 12, 45, 12, 61, 12, 45,
+		44, 173,
+	}
+	explodeLengthLengths   = []byte{2, 35, 36, 53, 38, 23}
+	explodeDistanceLengths = []byte{2, 20, 53, 230, 247, 151, 248}
+	explodeLengthBase      = [16]int{3, 2, 4, 5, 6, 7, 8, 9, 10, 12, 16, 24, 40, 72, 136, 264}
+	explodeLengthExtra     = [16]int{0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8}
+
+	explodeLiteralCode  = newHuffman(explodeLiteralLengths)
+	explodeLengthCode   = newHuffman(explodeLengthLengths)
+	explodeDistanceCode = newHuffman(explodeDistanceLengths)
+)
+
+// huffman is a canonical huffman code
+type huffman struct {
+	count  [explodeMaxBits + 1]int // number of symbols of each length
+	symbol []int                   // symbols ordered by length
+}
+
+// newHuffman builds a code from the compact form, where each byte holds a code
+// length in the low nibble and one less than the number of symbols with it in the high nibble
+func newHuffman(compact []byte) *huffman {
+	lengths := make([]int, 0)
+
+	for _, b := range compact {
+		for n := int(b>>4) + 1; n > 0; n-- {
+			lengths = append(lengths, int(b&0x0F))
+		}
+	}
+
+	h := &huffman{symbol: make([]int, len(lengths))}
+
+	for _, l := range lengths {
+		h.count[l]++
+	}
+
+	var offsets [explodeMaxBits + 1]int
+
+	for l := 1; l < explodeMaxBits; l++ {
+		offsets[l+1] = offsets[l] + h.count[l]
+	}
+
+	for symbol, l := range lengths {
+		if l != 0 {
+			h.symbol[offsets[l]] = symbol
+			offsets[l]++
+		}
+	}
+
+	return h
+}
+
+type explodeState struct {
+	in     []byte
+	pos    int
+	bitBuf uint32
+	bitCnt uint
+}
+
+var errExplodeTruncated = fmt.Errorf("%w, implode: data is truncated", ErrDecompress)
+
+// bits reads n bits, least significant bit first
+func (s *explodeState) bits(n uint) (int, error) {
+	for s.bitCnt < n {
+		if s.pos >= len(s.in) {
+			return 0, errExplodeTruncated
+		}
+
+		s.bitBuf |= uint32(s.in[s.pos]) << s.bitCnt
+		s.pos++
+		s.bitCnt += 8
+	}
+
+	v := int(s.bitBuf & (1<<n - 1))
+	s.bitBuf >>= n
+	s.bitCnt -= n
+
+	return v, nil
+}
+
+// decode reads a symbol, the codes are stored with their bits inverted
+func (s *explodeState) decode(h *huffman) (int, error) {
+	code, first, index := 0, 0, 0
+
+	for l := 1; l <= explodeMaxBits; l++ {
+		bit, err := s.bits(1)
+		if err != nil {
+			return 0, err
+		}
+
+		code |= bit ^ 1
+		count := h.count[l]
+
+		if code < first+count {
+			return h.symbol[index+code-first], nil
+		}
+
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+
+	return 0, fmt.Errorf("%w, implode: bad code", ErrDecompress)
+}
+
+func explode(data []byte) ([]byte, error) {
+	s := &explodeState{in: data}
+
+	codedLiterals, err := s.bits(8)
+	if err != nil {
+		return nil, err
+	}
+
+	dictBits, err := s.bits(8)
+	if err != nil {
+		return nil, err
+	}
+
+	if codedLiterals > 1 || dictBits < explodeMinDictBits || dictBits > explodeMaxDictBits {
+		return nil, fmt.Errorf("%w, implode: bad header", ErrDecompress)
+	}
+
+	out := make([]byte, 0, len(data)*2)
+
+	for {
+		isCopy, err := s.bits(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if isCopy == 0 {
+			var literal int
+
+			if codedLiterals == 1 {
+				literal, err = s.decode(explodeLiteralCode)
+			} else {
+				literal, err = s.bits(8)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, byte(literal))
+
+			continue
+		}
+
+		symbol, err := s.decode(explodeLengthCode)
+		if err != nil {
+			return nil, err
+		}
+
+		extra, err := s.bits(uint(explodeLengthExtra[symbol]))
+		if err != nil {
+			return nil, err
+		}
+
+		length := explodeLengthBase[symbol] + extra
+		if length == explodeEndLength {
+			return out, nil
+		}
+
+		lowBits := uint(dictBits)
+		if length == 2 {
+			lowBits = explodeShortDistBits
+		}
+
+		high, err := s.decode(explodeDistanceCode)
+		if err != nil {
+			return nil, err
+		}
+
+		low, err := s.bits(lowBits)
+		if err != nil {
+			return nil, err
+		}
+
+		dist := high<<lowBits + low + 1
+		if dist > len(out) {
+			return nil, fmt.Errorf("%w, implode: distance too far back", ErrDecompress)
+		}
+
+		// the copy can overlap the bytes it produces
+		for from := len(out) - dist; length > 0; length, from = length-1, from+1 {
+			out = append(out, out[from])
+		}
+	}
+}
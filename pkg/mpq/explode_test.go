@@ -0,0 +1,157 @@
+package mpq
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+const testDictBits = 4
+
+// bitWriter writes bits least significant bit first, like explodeState reads them
+type bitWriter struct {
+	out    []byte
+	bitBuf uint32
+	bitCnt uint
+}
+
+func (w *bitWriter) bits(v int, n uint) {
+	w.bitBuf |= uint32(v) << w.bitCnt
+	w.bitCnt += n
+
+	for w.bitCnt >= 8 {
+		w.out = append(w.out, byte(w.bitBuf))
+		w.bitBuf >>= 8
+		w.bitCnt -= 8
+	}
+}
+
+// code writes the huffman code of the symbol, inverted, see explodeState.decode
+func (w *bitWriter) code(h *huffman, symbol int) {
+	code, first, index := 0, 0, 0
+
+	for l := 1; l <= explodeMaxBits; l++ {
+		for i := 0; i < h.count[l]; i++ {
+			if h.symbol[index+i] == symbol {
+				code = first + i
+
+				for bit := l - 1; bit >= 0; bit-- {
+					w.bits((code>>bit)&1^1, 1)
+				}
+
+				return
+			}
+		}
+
+		index += h.count[l]
+		first = (first + h.count[l]) << 1
+	}
+
+	panic("symbol has no code")
+}
+
+func (w *bitWriter) length(length int) {
+	for symbol := range explodeLengthBase {
+		base, extra := explodeLengthBase[symbol], explodeLengthExtra[symbol]
+
+		if length >= base && length < base+1<<extra {
+			w.code(explodeLengthCode, symbol)
+			w.bits(length-base, uint(extra))
+
+			return
+		}
+	}
+
+	panic("length has no code")
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.bitCnt > 0 {
+		w.out = append(w.out, byte(w.bitBuf))
+	}
+
+	return w.out
+}
+
+// implode compresses data with uncoded literals and greedy matches of at least
+// three bytes, which is enough to produce streams that explode has to undo
+func implode(data []byte) []byte {
+	const (
+		minMatch = 3
+		maxMatch = explodeEndLength - 1
+		maxDist  = 64 << testDictBits
+	)
+
+	w := &bitWriter{}
+	w.bits(0, 8)
+	w.bits(testDictBits, 8)
+
+	for pos := 0; pos < len(data); {
+		bestLength, bestDist := 0, 0
+
+		for dist := 1; dist <= maxDist && dist <= pos; dist++ {
+			length := 0
+			for length < maxMatch && pos+length < len(data) && data[pos+length] == data[pos-dist+length] {
+				length++
+			}
+
+			if length > bestLength {
+				bestLength, bestDist = length, dist
+			}
+		}
+
+		if bestLength < minMatch {
+			w.bits(0, 1)
+			w.bits(int(data[pos]), 8)
+			pos++
+
+			continue
+		}
+
+		w.bits(1, 1)
+		w.length(bestLength)
+		w.code(explodeDistanceCode, (bestDist-1)>>testDictBits)
+		w.bits((bestDist-1)&(1<<testDictBits-1), testDictBits)
+
+		pos += bestLength
+	}
+
+	w.bits(1, 1)
+	w.length(explodeEndLength)
+
+	return w.flush()
+}
+
+func TestExplodeKnownStream(t *testing.T) {
+	// the example from blast.c
+	data, err := explode([]byte{0x00, 0x04, 0x82, 0x24, 0x25, 0x8f, 0x80, 0x7f})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "AIAIAIAIAIAIA" {
+		t.Fatalf("expected AIAIAIAIAIAIA, got %q", data)
+	}
+}
+
+func TestExplodeRoundTrip(t *testing.T) {
+	data := testFileData()
+	compressed := implode(data)
+
+	if len(compressed) >= len(data) {
+		t.Fatalf("expected the data to get smaller, %d >= %d", len(compressed), len(data))
+	}
+
+	exploded, err := explode(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(exploded, data) {
+		t.Fatal("exploded data differs")
+	}
+
+	if _, err := explode(compressed[:len(compressed)/2]); !errors.Is(err, ErrDecompress) {
+		t.Fatalf("truncated stream, expected %v, got %v", ErrDecompress, err)
+	}
+}
@@ -0,0 +1,328 @@
+// Package mpq is a read-only reader for the MPQ archives that the game data is stored in.
+package mpq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	headerSignature      = "MPQ\x1a"
+	headerSearchStep     = 512
+	headerSize           = 32
+	hashEntrySize        = 16
+	blockEntrySize       = 16
+	baseSectorSize       = 512
+	hashTableKeyName     = "(hash table)"
+	blockTableKeyName    = "(block table)"
+	listfileName         = "(listfile)"
+	hashEntryEmpty       = 0xFFFFFFFF
+	hashEntryDeleted     = 0xFFFFFFFE
+	maxSectorSizeShift   = 16
+	maxTableEntries      = 1 << 20
+	listfileSeparators   = ";\r\n"
+	errFmtReadFile       = "could not read %q, %w"
+	errFmtOpenArchive    = "could not open archive, %w"
+	errFmtCorruptArchive = "%w, %s"
+)
+
+// Errors returned by the archive
+var (
+	ErrNotFound     = errors.New("file not found in archive")
+	ErrNoHeader     = errors.New("no mpq header found")
+	ErrCorrupt      = errors.New("corrupt archive")
+	ErrCompression  = errors.New("unsupported compression")
+	ErrNoListfile   = errors.New("archive has no listfile")
+	ErrDecompress   = errors.New("could not decompress")
+	ErrFileTooLarge = errors.New("file larger than the archive")
+)
+
+type header struct {
+	HeaderSize        uint32
+	ArchiveSize       uint32
+	FormatVersion     uint16
+	SectorSizeShift   uint16
+	HashTableOffset   uint32
+	BlockTableOffset  uint32
+	HashTableEntries  uint32
+	BlockTableEntries uint32
+}
+
+type hashEntry struct {
+	nameA      uint32
+	nameB      uint32
+	locale     uint16
+	platform   uint16
+	blockIndex uint32
+}
+
+// Archive is an open MPQ archive. It is safe for concurrent use: the files can be
+// read from several goroutines at the same time, because the tables are only
+// written by Open and New, and the file data is read with ReadAt, which does not
+// share a file offset between readers.
+type Archive struct {
+	r      io.ReaderAt
+	closer io.Closer
+	offset int64 // where the archive starts, it can be preceded by other data
+	size   int64
+	header header
+	hashes []hashEntry
+	blocks []blockEntry
+}
+
+// Open opens the archive at the given path
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf(errFmtOpenArchive, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf(errFmtOpenArchive, err)
+	}
+
+	a, err := New(f, info.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	a.closer = f
+
+	return a, nil
+}
+
+// New reads the archive from r, which holds size bytes
+func New(r io.ReaderAt, size int64) (*Archive, error) {
+	a := &Archive{r: r, size: size}
+
+	if err := a.readHeader(); err != nil {
+		return nil, err
+	}
+
+	if err := a.readHashTable(); err != nil {
+		return nil, err
+	}
+
+	if err := a.readBlockTable(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Close closes the underlying file, if the archive was opened with Open
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+
+	return a.closer.Close()
+}
+
+func (a *Archive) readHeader() error {
+	buf := make([]byte, headerSize+len(headerSignature))
+
+	for offset := int64(0); offset+headerSize <= a.size; offset += headerSearchStep {
+		if _, err := a.r.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf(errFmtOpenArchive, err)
+		}
+
+		if string(buf[:len(headerSignature)]) != headerSignature {
+			continue
+		}
+
+		a.offset = offset
+
+		r := bytes.NewReader(buf[len(headerSignature):])
+		if err := binary.Read(r, binary.LittleEndian, &a.header); err != nil {
+			return fmt.Errorf(errFmtOpenArchive, err)
+		}
+
+		if a.header.SectorSizeShift > maxSectorSizeShift {
+			return fmt.Errorf(errFmtCorruptArchive, ErrCorrupt, "bad sector size")
+		}
+
+		return nil
+	}
+
+	return ErrNoHeader
+}
+
+// readTable reads and decrypts a table of 16 byte entries
+func (a *Archive) readTable(offset, entries uint32, keyName string) ([]uint32, error) {
+	if entries > maxTableEntries {
+		return nil, fmt.Errorf(errFmtCorruptArchive, ErrCorrupt, keyName+" is too large")
+	}
+
+	const wordsPerEntry = hashEntrySize / 4
+
+	buf := make([]byte, entries*hashEntrySize)
+
+	if _, err := a.r.ReadAt(buf, a.offset+int64(offset)); err != nil {
+		return nil, fmt.Errorf(errFmtCorruptArchive, ErrCorrupt, "could not read "+keyName)
+	}
+
+	words := make([]uint32, entries*wordsPerEntry)
+
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(buf[i*4:])
+	}
+
+	decrypt(words, hashString(keyName, hashFileKey))
+
+	return words, nil
+}
+
+func (a *Archive) readHashTable() error {
+	words, err := a.readTable(a.header.HashTableOffset, a.header.HashTableEntries, hashTableKeyName)
+	if err != nil {
+		return err
+	}
+
+	a.hashes = make([]hashEntry, a.header.HashTableEntries)
+
+	for i := range a.hashes {
+		w := words[i*4:]
+
+		a.hashes[i] = hashEntry{
+			nameA:      w[0],
+			nameB:      w[1],
+			locale:     uint16(w[2]),
+			platform:   uint16(w[2] >> 16),
+			blockIndex: w[3],
+		}
+	}
+
+	return nil
+}
+
+func (a *Archive) readBlockTable() error {
+	words, err := a.readTable(a.header.BlockTableOffset, a.header.BlockTableEntries, blockTableKeyName)
+	if err != nil {
+		return err
+	}
+
+	a.blocks = make([]blockEntry, a.header.BlockTableEntries)
+
+	for i := range a.blocks {
+		w := words[i*4:]
+
+		a.blocks[i] = blockEntry{
+			offset:         w[0],
+			compressedSize: w[1],
+			fileSize:       w[2],
+			flags:          blockFlag(w[3]),
+		}
+	}
+
+	return nil
+}
+
+// sectorSize returns the size of the sectors that the files are split into
+func (a *Archive) sectorSize() uint32 {
+	return baseSectorSize << a.header.SectorSizeShift
+}
+
+// lookup finds the block of a file, nil is returned if the archive does not contain it
+func (a *Archive) lookup(name string) *blockEntry {
+	if len(a.hashes) == 0 {
+		return nil
+	}
+
+	numHashes := uint32(len(a.hashes))
+	start := hashString(name, hashTableOffset) % numHashes
+	nameA, nameB := hashString(name, hashNameA), hashString(name, hashNameB)
+
+	for i := uint32(0); i < numHashes; i++ {
+		h := &a.hashes[(start+i)%numHashes]
+
+		if h.blockIndex == hashEntryEmpty {
+			return nil
+		}
+
+		if h.blockIndex == hashEntryDeleted || h.nameA != nameA || h.nameB != nameB {
+			continue
+		}
+
+		if h.blockIndex >= uint32(len(a.blocks)) {
+			return nil
+		}
+
+		b := &a.blocks[h.blockIndex]
+
+		if !b.hasFlag(flagExists) {
+			return nil
+		}
+
+		return b
+	}
+
+	return nil
+}
+
+// Contains returns true if the archive contains the file, names are case-insensitive
+// and either slashes or backslashes can be used as separators
+func (a *Archive) Contains(name string) bool {
+	return a.lookup(name) != nil
+}
+
+// ReadFile reads the whole contents of a file in the archive
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	b := a.lookup(name)
+	if b == nil {
+		return nil, fmt.Errorf(errFmtReadFile, name, ErrNotFound)
+	}
+
+	data, err := a.readBlock(name, b)
+	if err != nil {
+		return nil, fmt.Errorf(errFmtReadFile, name, err)
+	}
+
+	return data, nil
+}
+
+// Listfile returns the names of the files listed in the archive's (listfile).
+// The listfile is not required to be complete, so files may be missing from it.
+func (a *Archive) Listfile() ([]string, error) {
+	if !a.Contains(listfileName) {
+		return nil, ErrNoListfile
+	}
+
+	data, err := a.ReadFile(listfileName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Split(splitListfile)
+
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names, scanner.Err()
+}
+
+func splitListfile(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if idx := bytes.IndexAny(data, listfileSeparators); idx >= 0 {
+		return idx + 1, data[:idx], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
@@ -0,0 +1,313 @@
+package mpq
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testHashTableEntries = 16
+
+// testFile is a file to put in a test archive
+type testFile struct {
+	name  string
+	data  []byte
+	flags blockFlag
+}
+
+// testFileData returns a few sectors of text, which compresses well
+func testFileData() []byte {
+	buf := &bytes.Buffer{}
+
+	for i := 0; buf.Len() < 3*baseSectorSize+100; i++ {
+		fmt.Fprintf(buf, "line %d of the file in the archive\n", i)
+	}
+
+	return buf.Bytes()
+}
+
+// encrypt is the inverse of decrypt
+func encrypt(data []uint32, key uint32) {
+	seed := uint32(0xEEEEEEEE)
+
+	for i := range data {
+		seed += cryptTable[decryptTableRow+(key&0xFF)]
+		ch := data[i]
+		data[i] = ch ^ (key + seed)
+		key = ((^key << 0x15) + 0x11111111) | (key >> 0x0B)
+		seed = ch + seed + (seed << 5) + 3
+	}
+}
+
+func encryptBytes(data []byte, key uint32) {
+	words := make([]uint32, len(data)/4)
+
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+
+	encrypt(words, key)
+
+	for i := range words {
+		binary.LittleEndian.PutUint32(data[i*4:], words[i])
+	}
+}
+
+// compressSector compresses a sector the way the block flags ask for, sectors
+// that do not get smaller are stored as they are
+func compressSector(sector []byte, flags blockFlag) []byte {
+	var compressed []byte
+
+	switch {
+	case flags&flagImplode != 0:
+		compressed = implode(sector)
+	case flags&flagCompress != 0:
+		buf := &bytes.Buffer{}
+		buf.WriteByte(byte(compressionZlib))
+
+		w := zlib.NewWriter(buf)
+		_, _ = w.Write(sector)
+		_ = w.Close()
+
+		compressed = buf.Bytes()
+	default:
+		return sector
+	}
+
+	if len(compressed) >= len(sector) {
+		return sector
+	}
+
+	return compressed
+}
+
+// encodeBlock returns the stored data of a file that starts at the given offset
+func encodeBlock(f testFile, offset uint32) []byte {
+	b := &blockEntry{offset: offset, fileSize: uint32(len(f.data)), flags: f.flags}
+	key := fileKey(f.name, b)
+
+	if b.hasFlag(flagSingleUnit) {
+		data := compressSector(append([]byte{}, f.data...), f.flags)
+
+		if b.hasFlag(flagEncrypted) {
+			encryptBytes(data, key)
+		}
+
+		return data
+	}
+
+	sectors := make([][]byte, 0)
+
+	for start := 0; start < len(f.data); start += baseSectorSize {
+		end := start + baseSectorSize
+		if end > len(f.data) {
+			end = len(f.data)
+		}
+
+		sectors = append(sectors, compressSector(append([]byte{}, f.data[start:end]...), f.flags))
+	}
+
+	data := make([]byte, 0)
+
+	if b.compressed() {
+		table := make([]uint32, len(sectors)+1)
+		table[0] = uint32(len(table) * 4)
+
+		for i, sector := range sectors {
+			table[i+1] = table[i] + uint32(len(sector))
+		}
+
+		if b.hasFlag(flagEncrypted) {
+			encrypt(table, key-1)
+		}
+
+		data = make([]byte, len(table)*4)
+
+		for i, offset := range table {
+			binary.LittleEndian.PutUint32(data[i*4:], offset)
+		}
+	}
+
+	for i, sector := range sectors {
+		if b.hasFlag(flagEncrypted) {
+			encryptBytes(sector, key+uint32(i))
+		}
+
+		data = append(data, sector...)
+	}
+
+	return data
+}
+
+// buildArchive returns an archive with the files and a listfile, the tables come
+// right after the header and are followed by the files
+func buildArchive(files ...testFile) []byte {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+
+	sort.Strings(names)
+
+	files = append(files, testFile{name: listfileName, data: []byte(strings.Join(names, "\r\n"))})
+
+	hashesAt := uint32(headerSize)
+	blocksAt := hashesAt + testHashTableEntries*hashEntrySize
+	offset := blocksAt + uint32(len(files))*blockEntrySize
+
+	hashes := make([]uint32, testHashTableEntries*4)
+	for i := range hashes {
+		hashes[i] = hashEntryEmpty
+	}
+
+	blocks := make([]uint32, 0, len(files)*4)
+	data := make([]byte, 0)
+
+	for idx, f := range files {
+		f.flags |= flagExists
+
+		stored := encodeBlock(f, offset)
+		blocks = append(blocks, offset, uint32(len(stored)), uint32(len(f.data)), uint32(f.flags))
+		data = append(data, stored...)
+		offset += uint32(len(stored))
+
+		slot := hashString(f.name, hashTableOffset) % testHashTableEntries
+		for hashes[slot*4+3] != hashEntryEmpty {
+			slot = (slot + 1) % testHashTableEntries
+		}
+
+		copy(hashes[slot*4:], []uint32{hashString(f.name, hashNameA), hashString(f.name, hashNameB), 0, uint32(idx)})
+	}
+
+	encrypt(hashes, hashString(hashTableKeyName, hashFileKey))
+	encrypt(blocks, hashString(blockTableKeyName, hashFileKey))
+
+	h := header{
+		HeaderSize:        headerSize,
+		ArchiveSize:       offset,
+		HashTableOffset:   hashesAt,
+		BlockTableOffset:  blocksAt,
+		HashTableEntries:  testHashTableEntries,
+		BlockTableEntries: uint32(len(files)),
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(headerSignature)
+	_ = binary.Write(buf, binary.LittleEndian, h)
+	buf.Truncate(headerSize)
+	_ = binary.Write(buf, binary.LittleEndian, hashes)
+	_ = binary.Write(buf, binary.LittleEndian, blocks)
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+func openTestArchive(t *testing.T, data []byte) *Archive {
+	t.Helper()
+
+	a, err := New(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a
+}
+
+func TestReadFile(t *testing.T) {
+	data := testFileData()
+
+	files := []testFile{
+		{name: `data\uncompressed.dcc`, data: data},
+		{name: `data\zlib.dcc`, data: data, flags: flagCompress},
+		{name: `data\implode.dcc`, data: data, flags: flagImplode},
+		{name: `data\encrypted.dcc`, data: data, flags: flagCompress | flagEncrypted},
+		{name: `data\fixkey.dcc`, data: data, flags: flagImplode | flagEncrypted | flagFixKey},
+		{name: `data\single.dcc`, data: data, flags: flagCompress | flagSingleUnit},
+		{name: `data\single-encrypted.dcc`, data: data, flags: flagSingleUnit | flagEncrypted},
+		{name: `data\small.dcc`, data: []byte("tiny"), flags: flagCompress},
+	}
+
+	a := openTestArchive(t, buildArchive(files...))
+
+	for _, f := range files {
+		got, err := a.ReadFile(f.name)
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+
+		if !bytes.Equal(got, f.data) {
+			t.Fatalf("%s: contents differ", f.name)
+		}
+
+		// make sure that the sectors were not stored as they are
+		if b := a.lookup(f.name); b.compressed() && len(f.data) > baseSectorSize && b.compressedSize >= b.fileSize {
+			t.Fatalf("%s: expected the file to be stored compressed", f.name)
+		}
+	}
+
+	if !a.Contains("DATA/ZLIB.DCC") {
+		t.Fatal("expected names to be case-insensitive and to accept slashes")
+	}
+
+	if _, err := a.ReadFile(`data\missing.dcc`); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v, got %v", ErrNotFound, err)
+	}
+
+	listed, err := a.Listfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listed) != len(files) {
+		t.Fatalf("expected %d names in the listfile, got %d", len(files), len(listed))
+	}
+}
+
+func TestCorruptArchive(t *testing.T) {
+	data := testFileData()
+	archive := buildArchive(
+		testFile{name: "plain.dcc", data: data},
+		testFile{name: "zlib.dcc", data: data, flags: flagCompress},
+	)
+
+	if _, err := New(bytes.NewReader(archive[:headerSize+8]), headerSize+8); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("truncated tables, expected %v, got %v", ErrCorrupt, err)
+	}
+
+	if _, err := New(bytes.NewReader(archive[4:]), int64(len(archive)-4)); !errors.Is(err, ErrNoHeader) {
+		t.Fatalf("missing header, expected %v, got %v", ErrNoHeader, err)
+	}
+
+	a := openTestArchive(t, archive)
+
+	for _, name := range []string{"plain.dcc", "zlib.dcc"} {
+		b := a.lookup(name)
+		truncated := openTestArchive(t, archive[:b.offset+b.compressedSize/2])
+
+		if _, err := truncated.ReadFile(name); !errors.Is(err, ErrFileTooLarge) {
+			t.Fatalf("%s: truncated file, expected %v, got %v", name, ErrFileTooLarge, err)
+		}
+	}
+
+	// the sector table of the compressed file points past the end of the file
+	b := a.lookup("zlib.dcc")
+	corrupt := append([]byte{}, archive...)
+	binary.LittleEndian.PutUint32(corrupt[b.offset+4:], b.compressedSize+1)
+
+	if _, err := openTestArchive(t, corrupt).ReadFile("zlib.dcc"); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("bad sector table, expected %v, got %v", ErrCorrupt, err)
+	}
+
+	// garbage instead of the zlib stream of the first sector
+	corrupt = append([]byte{}, archive...)
+	binary.LittleEndian.PutUint32(corrupt[b.offset+binary.LittleEndian.Uint32(archive[b.offset:])+1:], 0xDEADBEEF)
+
+	if _, err := openTestArchive(t, corrupt).ReadFile("zlib.dcc"); !errors.Is(err, ErrDecompress) {
+		t.Fatalf("bad zlib stream, expected %v, got %v", ErrDecompress, err)
+	}
+}
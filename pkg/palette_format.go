@@ -0,0 +1,334 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gpl "github.com/gravestench/gpl/pkg"
+)
+
+// PaletteFormat is a file format that a palette can be written in
+type PaletteFormat int
+
+// Palette formats
+const (
+	// PaletteFormatGPL is a GIMP palette
+	PaletteFormatGPL PaletteFormat = iota
+	// PaletteFormatACT is an Adobe color table
+	PaletteFormatACT
+	// PaletteFormatJASC is a JASC-PAL (Paint Shop Pro) palette
+	PaletteFormatJASC
+	// PaletteFormatDAT is the raw BGR palette format used by the game (pal.dat)
+	PaletteFormatDAT
+	// PaletteFormatPNG is a PNG image of a grid of color swatches
+	PaletteFormatPNG
+)
+
+const (
+	bytesPerColor     = 3
+	swatchesPerRow    = 16
+	defaultSwatchSize = 16
+	jascHeader        = "JASC-PAL"
+	jascVersion       = "0100"
+	actFooterSize     = 4
+	gplPaletteName    = "dcc"
+	errFmtPalette     = "%v palette, %w"
+)
+
+var paletteFormatNames = map[PaletteFormat]string{
+	PaletteFormatGPL:  "gpl",
+	PaletteFormatACT:  "act",
+	PaletteFormatJASC: "jasc",
+	PaletteFormatDAT:  "dat",
+	PaletteFormatPNG:  "png",
+}
+
+var paletteFormatExtensions = map[string]PaletteFormat{
+	".gpl": PaletteFormatGPL,
+	".act": PaletteFormatACT,
+	".pal": PaletteFormatJASC,
+	".dat": PaletteFormatDAT,
+	".png": PaletteFormatPNG,
+}
+
+func (f PaletteFormat) String() string {
+	s, ok := paletteFormatNames[f]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// PaletteFormatFromString returns the palette format with the given name,
+// as yielded by PaletteFormat.String
+func PaletteFormatFromString(s string) (PaletteFormat, error) {
+	for format, name := range paletteFormatNames {
+		if strings.EqualFold(name, s) {
+			return format, nil
+		}
+	}
+
+	return PaletteFormatGPL, fmt.Errorf("unknown palette format %q", s)
+}
+
+// PaletteFormatFromPath determines the palette format from a file extension:
+// .gpl, .act, .pal (JASC-PAL), .dat, or .png
+func PaletteFormatFromPath(path string) (PaletteFormat, error) {
+	format, ok := paletteFormatExtensions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return PaletteFormatGPL, fmt.Errorf("unknown palette file extension for %q", path)
+	}
+
+	return format, nil
+}
+
+// EncodePalette writes the palette to w in the given format
+func EncodePalette(w io.Writer, p color.Palette, format PaletteFormat) (err error) {
+	switch format {
+	case PaletteFormatGPL:
+		err = gpl.FromPalette(p).Encode(gplPaletteName, w)
+	case PaletteFormatACT:
+		err = encodeRGB(w, p, false)
+	case PaletteFormatJASC:
+		err = encodeJASC(w, p)
+	case PaletteFormatDAT:
+		err = encodeRGB(w, p, true)
+	case PaletteFormatPNG:
+		err = png.Encode(w, PaletteSwatches(p, defaultSwatchSize))
+	default:
+		return fmt.Errorf("unknown palette format %d", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf(errFmtPalette, format, err)
+	}
+
+	return nil
+}
+
+// DecodePalette reads a palette in the given format from r
+func DecodePalette(r io.Reader, format PaletteFormat) (p color.Palette, err error) {
+	switch format {
+	case PaletteFormatGPL:
+		var g gpl.GPL
+
+		g, err = gpl.Decode(r)
+		p = color.Palette(g)
+	case PaletteFormatACT:
+		p, err = decodeRGB(r, false)
+	case PaletteFormatJASC:
+		p, err = decodeJASC(r)
+	case PaletteFormatDAT:
+		p, err = decodeRGB(r, true)
+	case PaletteFormatPNG:
+		p, err = decodeSwatches(r)
+	default:
+		return nil, fmt.Errorf("unknown palette format %d", format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(errFmtPalette, format, err)
+	}
+
+	return p, nil
+}
+
+// PaletteSwatches renders the palette as a grid of square swatches,
+// 16 swatches per row, each swatch being size pixels wide and high.
+func PaletteSwatches(p color.Palette, size int) *image.RGBA {
+	rows := (len(p) + swatchesPerRow - 1) / swatchesPerRow
+	img := image.NewRGBA(image.Rect(0, 0, swatchesPerRow*size, rows*size))
+
+	for idx := range p {
+		x, y := (idx%swatchesPerRow)*size, (idx/swatchesPerRow)*size
+		swatch := image.Rect(x, y, x+size, y+size)
+
+		draw.Draw(img, swatch, &image.Uniform{C: opaque(p[idx])}, image.Point{}, draw.Src)
+	}
+
+	return img
+}
+
+// encodeRGB writes three bytes per color, in BGR order when bgr is set.
+// Both ACT and DAT palettes always contain 256 colors.
+func encodeRGB(w io.Writer, p color.Palette, bgr bool) error {
+	buf := make([]byte, numColorsInPalette*bytesPerColor)
+
+	for idx := 0; idx < numColorsInPalette && idx < len(p); idx++ {
+		r, g, b := rgb8(p[idx])
+		if bgr {
+			r, b = b, r
+		}
+
+		buf[idx*bytesPerColor], buf[idx*bytesPerColor+1], buf[idx*bytesPerColor+2] = r, g, b
+	}
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+func decodeRGB(r io.Reader, bgr bool) (color.Palette, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < numColorsInPalette*bytesPerColor {
+		return nil, fmt.Errorf("palette data too short, %d bytes", len(data))
+	}
+
+	numColors := numColorsInPalette
+
+	// ACT files may have a footer with the number of colors (big endian)
+	if !bgr && len(data) >= numColorsInPalette*bytesPerColor+actFooterSize {
+		footer := data[numColorsInPalette*bytesPerColor:]
+		if n := int(footer[0])<<8 | int(footer[1]); n > 0 && n <= numColorsInPalette {
+			numColors = n
+		}
+	}
+
+	p := make(color.Palette, numColors)
+
+	for idx := range p {
+		c := data[idx*bytesPerColor : (idx+1)*bytesPerColor]
+		if bgr {
+			p[idx] = color.RGBA{R: c[2], G: c[1], B: c[0], A: math.MaxUint8}
+		} else {
+			p[idx] = color.RGBA{R: c[0], G: c[1], B: c[2], A: math.MaxUint8}
+		}
+	}
+
+	return p, nil
+}
+
+func encodeJASC(w io.Writer, p color.Palette) error {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "%s\r\n%s\r\n%d\r\n", jascHeader, jascVersion, len(p))
+
+	for idx := range p {
+		r, g, b := rgb8(p[idx])
+		fmt.Fprintf(buf, "%d %d %d\r\n", r, g, b)
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func decodeJASC(r io.Reader) (color.Palette, error) {
+	const numHeaderLines = 3
+
+	scanner := bufio.NewScanner(r)
+	lines := make([]string, 0)
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) < numHeaderLines || lines[0] != jascHeader {
+		return nil, errors.New("missing JASC-PAL header")
+	}
+
+	numColors, err := strconv.Atoi(lines[2])
+	if err != nil {
+		return nil, fmt.Errorf("bad color count, %w", err)
+	}
+
+	if numColors < 0 || numColors > numColorsInPalette {
+		const fmtErr = "bad color count, %d is not between 0 and %d"
+		return nil, fmt.Errorf(fmtErr, numColors, numColorsInPalette)
+	}
+
+	if len(lines)-numHeaderLines < numColors {
+		const fmtErr = "expected %d colors, found %d"
+		return nil, fmt.Errorf(fmtErr, numColors, len(lines)-numHeaderLines)
+	}
+
+	p := make(color.Palette, numColors)
+
+	for idx := range p {
+		var c [bytesPerColor]uint8
+
+		fields := strings.Fields(lines[numHeaderLines+idx])
+		if len(fields) < bytesPerColor {
+			return nil, fmt.Errorf("color %d, expected 3 components", idx)
+		}
+
+		for component := range c {
+			v, err := strconv.ParseUint(fields[component], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("color %d, %w", idx, err)
+			}
+
+			c[component] = uint8(v)
+		}
+
+		p[idx] = color.RGBA{R: c[0], G: c[1], B: c[2], A: math.MaxUint8}
+	}
+
+	return p, nil
+}
+
+// decodeSwatches reads back a swatch grid as written by PaletteSwatches,
+// sampling the center of each swatch
+func decodeSwatches(r io.Reader) (color.Palette, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	size := bounds.Dx() / swatchesPerRow
+
+	if size < 1 {
+		return nil, errors.New("image is too small to be a palette swatch grid")
+	}
+
+	rows := bounds.Dy() / size
+	p := make(color.Palette, 0, rows*swatchesPerRow)
+
+	for y := 0; y < rows; y++ {
+		for x := 0; x < swatchesPerRow; x++ {
+			c := img.At(bounds.Min.X+x*size+size/2, bounds.Min.Y+y*size+size/2)
+			r, g, b := rgb8(c)
+			p = append(p, color.RGBA{R: r, G: g, B: b, A: math.MaxUint8})
+		}
+	}
+
+	return p, nil
+}
+
+func rgb8(c color.Color) (r, g, b uint8) {
+	const shift = 8
+
+	r32, g32, b32, _ := c.RGBA()
+
+	return uint8(r32 >> shift), uint8(g32 >> shift), uint8(b32 >> shift)
+}
+
+func opaque(c color.Color) color.Color {
+	r, g, b := rgb8(c)
+
+	return color.RGBA{R: r, G: g, B: b, A: math.MaxUint8}
+}
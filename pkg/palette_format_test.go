@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestPaletteRoundTrip(t *testing.T) {
+	p := *DefaultPalette()
+	p[1] = color.RGBA{R: 200, G: 100, B: 50, A: 255}
+
+	for format := range paletteFormatNames {
+		buf := &bytes.Buffer{}
+
+		if err := EncodePalette(buf, p, format); err != nil {
+			t.Fatalf("%s: encoding, %v", format, err)
+		}
+
+		decoded, err := DecodePalette(buf, format)
+		if err != nil {
+			t.Fatalf("%s: decoding, %v", format, err)
+		}
+
+		if len(decoded) != len(p) {
+			t.Fatalf("%s: expected %d colors, got %d", format, len(p), len(decoded))
+		}
+
+		for idx := range p {
+			r1, g1, b1 := rgb8(p[idx])
+			r2, g2, b2 := rgb8(decoded[idx])
+
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				t.Fatalf("%s: color %d, expected %v, got %v", format, idx, p[idx], decoded[idx])
+			}
+		}
+	}
+}
+
+func TestDecodeJASCBadColorCount(t *testing.T) {
+	for _, count := range []string{"-1", "257", "many"} {
+		data := strings.Join([]string{jascHeader, jascVersion, count, "0 0 0"}, "\r\n")
+
+		if _, err := DecodePalette(strings.NewReader(data), PaletteFormatJASC); err == nil {
+			t.Errorf("color count %s, expected an error", count)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrBadFrameCount is returned when retiming to less than one frame
+var ErrBadFrameCount = errors.New("frame count must be at least 1")
+
+// RetimeCurve weights which source frames are used when retiming. With the
+// linear curve the frames are dropped or repeated evenly.
+type RetimeCurve int
+
+// Retime curves
+const (
+	// RetimeLinear spreads the source frames evenly
+	RetimeLinear RetimeCurve = iota
+	// RetimeEaseIn gives more frames to the start of the animation
+	RetimeEaseIn
+	// RetimeEaseOut gives more frames to the end of the animation
+	RetimeEaseOut
+	// RetimeEaseInOut gives more frames to the start and the end of the animation
+	RetimeEaseInOut
+)
+
+var retimeCurveNames = map[RetimeCurve]string{
+	RetimeLinear:    "linear",
+	RetimeEaseIn:    "ease-in",
+	RetimeEaseOut:   "ease-out",
+	RetimeEaseInOut: "ease-in-out",
+}
+
+func (c RetimeCurve) String() string {
+	s, ok := retimeCurveNames[c]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// RetimeCurveFromString returns the retime curve with the given name, as
+// yielded by RetimeCurve.String
+func RetimeCurveFromString(s string) (RetimeCurve, error) {
+	for curve, name := range retimeCurveNames {
+		if strings.EqualFold(name, s) {
+			return curve, nil
+		}
+	}
+
+	return RetimeLinear, fmt.Errorf("unknown retime curve %q", s)
+}
+
+// Apply maps the position t in the target animation, from 0 to 1, to the
+// position in the source animation
+func (c RetimeCurve) Apply(t float64) float64 {
+	const half = 0.5
+
+	switch c {
+	case RetimeEaseIn:
+		return t * t
+	case RetimeEaseOut:
+		return 1 - (1-t)*(1-t)
+	case RetimeEaseInOut:
+		if t < half {
+			return 2 * t * t //nolint:gomnd // quadratic ease
+		}
+
+		return 1 - 2*(1-t)*(1-t) //nolint:gomnd // quadratic ease
+	default:
+		return t
+	}
+}
+
+// RetimeMapping returns the source frame used for each of the target frames
+// when n frames are retimed to m frames. The curve maps a position in the
+// target animation, from 0 to 1, to a position in the source animation.
+// There must be at least one source and one target frame.
+func RetimeMapping(n, m int, curve func(t float64) float64) ([]int, error) {
+	if m < 1 {
+		return nil, fmt.Errorf("%w, got %d", ErrBadFrameCount, m)
+	}
+
+	if n < 1 {
+		return nil, ErrNoFrames
+	}
+
+	mapping := make([]int, m)
+
+	for idx := range mapping {
+		// a tiny bias keeps exact frame boundaries from rounding down
+		const bias = 1e-9
+
+		src := int(math.Floor(curve(float64(idx)/float64(m))*float64(n) + bias))
+
+		switch {
+		case src < 0:
+			src = 0
+		case src >= n:
+			src = n - 1
+		}
+
+		mapping[idx] = src
+	}
+
+	return mapping, nil
+}
+
+// Retime changes the number of frames of every direction to m, dropping or
+// repeating frames as weighted by the curve. Every direction is retimed the
+// same way, so they keep the same number of frames.
+func (d *DCC) Retime(m int, curve RetimeCurve) error {
+	return d.RetimeFunc(m, curve.Apply)
+}
+
+// RetimeFunc is like Retime, with a custom curve, see RetimeMapping
+func (d *DCC) RetimeFunc(m int, curve func(t float64) float64) error {
+	if m < 1 {
+		return fmt.Errorf("%w, got %d", ErrBadFrameCount, m)
+	}
+
+	if len(d.directions) == 0 {
+		return nil
+	}
+
+	mapping, err := RetimeMapping(len(d.directions[0].frames), m, curve)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range d.directions {
+		dir.edit(func() {
+			retimed := make([]*Frame, m)
+
+			for idx, src := range mapping {
+				retimed[idx] = dir.frames[src].clone()
+			}
+
+			dir.frames = retimed
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestRetimeMapping(t *testing.T) {
+	mapping, err := RetimeMapping(4, 8, RetimeLinear.Apply)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{0, 0, 1, 1, 2, 2, 3, 3}; !reflect.DeepEqual(mapping, want) {
+		t.Fatalf("expected %v, got %v", want, mapping)
+	}
+
+	if _, err := RetimeMapping(0, 8, RetimeLinear.Apply); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("no source frames, expected %v, got %v", ErrNoFrames, err)
+	}
+
+	if _, err := RetimeMapping(4, 0, RetimeLinear.Apply); !errors.Is(err, ErrBadFrameCount) {
+		t.Fatalf("no target frames, expected %v, got %v", ErrBadFrameCount, err)
+	}
+}
+
+func TestRetimeWithoutFrames(t *testing.T) {
+	d := newTestDCC(t, []*image.Paletted{}, []*image.Paletted{})
+
+	if err := d.Retime(4, RetimeLinear); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("expected %v, got %v", ErrNoFrames, err)
+	}
+}
@@ -0,0 +1,394 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// ErrScaleFactor is returned when a scale mode does not support the scale factor
+var ErrScaleFactor = errors.New("unsupported scale factor")
+
+// ScaleMode is the algorithm used to scale frames. Every mode works on palette
+// indices, so a scaled frame only uses colors from the palette.
+type ScaleMode int
+
+// Scale modes
+const (
+	// ScaleNearest repeats or drops pixels, and supports any factor
+	ScaleNearest ScaleMode = iota
+	// ScaleScale2x doubles the size, rounding off the staircases of diagonal edges
+	ScaleScale2x
+	// ScaleScale3x triples the size, like ScaleScale2x
+	ScaleScale3x
+	// ScaleEPX is the original name of the Scale2x algorithm, it gives the same result
+	ScaleEPX
+	// ScaleHQ2x doubles the size, blending the colors along edges like hq2x.
+	// The blended colors are replaced with the closest palette color.
+	ScaleHQ2x
+)
+
+var scaleModeNames = map[ScaleMode]string{
+	ScaleNearest: "nearest",
+	ScaleScale2x: "scale2x",
+	ScaleScale3x: "scale3x",
+	ScaleEPX:     "epx",
+	ScaleHQ2x:    "hq2x",
+}
+
+func (m ScaleMode) String() string {
+	s, ok := scaleModeNames[m]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// ScaleModeFromString returns the scale mode with the given name, as
+// yielded by ScaleMode.String
+func ScaleModeFromString(s string) (ScaleMode, error) {
+	for mode, name := range scaleModeNames {
+		if strings.EqualFold(name, s) {
+			return mode, nil
+		}
+	}
+
+	return ScaleNearest, fmt.Errorf("unknown scale mode %q", s)
+}
+
+// Factor returns the scale factor of the mode, or 0 if it supports any factor
+func (m ScaleMode) Factor() int {
+	switch m {
+	case ScaleScale2x, ScaleEPX, ScaleHQ2x:
+		return 2 //nolint:gomnd // scale factor
+	case ScaleScale3x:
+		return 3 //nolint:gomnd // scale factor
+	default:
+		return 0
+	}
+}
+
+func (m ScaleMode) check(factor float64) error {
+	if fixed := m.Factor(); fixed != 0 && factor != float64(fixed) {
+		return fmt.Errorf("%w: %s only scales by %d, got %v", ErrScaleFactor, m, fixed, factor)
+	}
+
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return fmt.Errorf("%w: %v", ErrScaleFactor, factor)
+	}
+
+	return nil
+}
+
+// ScaledImage returns a scaled copy of the frame as a paletted image. The image
+// bounds are relative to the anchor, like those of Paletted, and are scaled
+// around it, so the anchor stays in place.
+func (f *Frame) ScaledImage(mode ScaleMode, factor float64) (*image.Paletted, error) {
+	if err := mode.check(factor); err != nil {
+		return nil, err
+	}
+
+	src := f.Paletted()
+
+	switch mode {
+	case ScaleScale2x, ScaleEPX:
+		return scalePixelArt(src, mode.Factor(), scale2x), nil
+	case ScaleScale3x:
+		return scalePixelArt(src, mode.Factor(), scale3x), nil
+	case ScaleHQ2x:
+		return scalePixelArt(src, mode.Factor(), newHQ2x(src.Palette).scale), nil
+	default:
+		return scaleNearest(src, factor), nil
+	}
+}
+
+// Scale scales the frame in place, see ScaledImage. The direction box is updated to match.
+func (f *Frame) Scale(mode ScaleMode, factor float64) error {
+	img, err := f.ScaledImage(mode, factor)
+	if err != nil {
+		return err
+	}
+
+	f.SetPixels(img)
+
+	return nil
+}
+
+// Scale scales every frame of the direction, see Frame.ScaledImage
+func (d *Direction) Scale(mode ScaleMode, factor float64) error {
+	scaled := make([]*image.Paletted, len(d.frames))
+
+	for idx, f := range d.frames {
+		img, err := f.ScaledImage(mode, factor)
+		if err != nil {
+			return err
+		}
+
+		scaled[idx] = img
+	}
+
+	d.edit(func() {
+		for idx, f := range d.frames {
+			f.setPixels(scaled[idx])
+		}
+	})
+
+	return nil
+}
+
+// Scale scales every frame of every direction, see Frame.ScaledImage
+func (d *DCC) Scale(mode ScaleMode, factor float64) error {
+	if err := mode.check(factor); err != nil {
+		return err
+	}
+
+	for _, dir := range d.directions {
+		if err := dir.Scale(mode, factor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scaleNearest(src *image.Paletted, factor float64) *image.Paletted {
+	r := src.Bounds()
+	scale := func(v int) int { return int(math.Floor(float64(v) * factor)) }
+
+	bounds := image.Rect(scale(r.Min.X), scale(r.Min.Y), scale(r.Max.X), scale(r.Max.Y))
+	if bounds.Dx() < 1 {
+		bounds.Max.X = bounds.Min.X + 1
+	}
+
+	if bounds.Dy() < 1 {
+		bounds.Max.Y = bounds.Min.Y + 1
+	}
+
+	dst := image.NewPaletted(bounds, src.Palette)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		srcY := int(math.Floor((float64(y) + 0.5) / factor)) //nolint:gomnd // pixel center
+
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			srcX := int(math.Floor((float64(x) + 0.5) / factor)) //nolint:gomnd // pixel center
+			dst.SetColorIndex(x, y, src.ColorIndexAt(srcX, srcY))
+		}
+	}
+
+	return dst
+}
+
+// neighborhood holds a pixel and its neighbors:
+//
+//	A B C
+//	D E F
+//	G H I
+type neighborhood struct {
+	A, B, C, D, E, F, G, H, I uint8
+}
+
+// pixelArtScaler returns the size*size block of pixels that replaces the center
+// pixel of the neighborhood, row by row
+type pixelArtScaler func(n *neighborhood) []uint8
+
+// scalePixelArt scales the image with a fixed factor scaler. The transparent
+// pixels around the image are scaled too, because edges can grow into them.
+func scalePixelArt(src *image.Paletted, size int, scaler pixelArtScaler) *image.Paletted {
+	r := src.Bounds()
+	grown := r.Inset(-1)
+	dst := image.NewPaletted(image.Rectangle{Min: grown.Min.Mul(size), Max: grown.Max.Mul(size)}, src.Palette)
+
+	for y := grown.Min.Y; y < grown.Max.Y; y++ {
+		for x := grown.Min.X; x < grown.Max.X; x++ {
+			at := func(dx, dy int) uint8 { return src.ColorIndexAt(x+dx, y+dy) }
+
+			block := scaler(&neighborhood{
+				A: at(-1, -1), B: at(0, -1), C: at(1, -1),
+				D: at(-1, 0), E: at(0, 0), F: at(1, 0),
+				G: at(-1, 1), H: at(0, 1), I: at(1, 1),
+			})
+
+			for idx, v := range block {
+				dst.SetColorIndex(x*size+idx%size, y*size+idx/size, v)
+			}
+		}
+	}
+
+	bounds := image.Rectangle{Min: r.Min.Mul(size), Max: r.Max.Mul(size)}
+
+	for y := dst.Rect.Min.Y; y < dst.Rect.Max.Y; y++ {
+		for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+			if dst.ColorIndexAt(x, y) != 0 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	return dst.SubImage(bounds).(*image.Paletted)
+}
+
+func scale2x(n *neighborhood) []uint8 {
+	// B is above, D to the left, F to the right and H below E
+	if n.B == n.H || n.D == n.F {
+		return []uint8{n.E, n.E, n.E, n.E}
+	}
+
+	return []uint8{
+		pick(n.D == n.B, n.D, n.E), pick(n.B == n.F, n.F, n.E),
+		pick(n.D == n.H, n.D, n.E), pick(n.H == n.F, n.F, n.E),
+	}
+}
+
+func scale3x(n *neighborhood) []uint8 {
+	if n.B == n.H || n.D == n.F {
+		return []uint8{n.E, n.E, n.E, n.E, n.E, n.E, n.E, n.E, n.E}
+	}
+
+	db, bf, dh, hf := n.D == n.B, n.B == n.F, n.D == n.H, n.H == n.F
+
+	return []uint8{
+		pick(db, n.D, n.E),
+		pick((db && n.E != n.C) || (bf && n.E != n.A), n.B, n.E),
+		pick(bf, n.F, n.E),
+		pick((db && n.E != n.G) || (dh && n.E != n.A), n.D, n.E),
+		n.E,
+		pick((bf && n.E != n.I) || (hf && n.E != n.C), n.F, n.E),
+		pick(dh, n.D, n.E),
+		pick((dh && n.E != n.I) || (hf && n.E != n.G), n.H, n.E),
+		pick(hf, n.F, n.E),
+	}
+}
+
+func pick(cond bool, a, b uint8) uint8 {
+	if cond {
+		return a
+	}
+
+	return b
+}
+
+// hq2x blends the colors along edges, and snaps the blended colors to the palette
+type hq2x struct {
+	palette    color.Palette
+	candidates []byte
+	closest    map[color.RGBA]uint8
+}
+
+func newHQ2x(p color.Palette) *hq2x {
+	h := &hq2x{palette: p, closest: make(map[color.RGBA]uint8)}
+
+	for idx := 1; idx < len(p) && idx < numColorsInPalette; idx++ {
+		h.candidates = append(h.candidates, byte(idx))
+	}
+
+	return h
+}
+
+func (h *hq2x) scale(n *neighborhood) []uint8 {
+	if h.similar(n.B, n.H) || h.similar(n.D, n.F) {
+		return []uint8{n.E, n.E, n.E, n.E}
+	}
+
+	return []uint8{
+		h.corner(n.E, n.B, n.D), h.corner(n.E, n.B, n.F),
+		h.corner(n.E, n.H, n.D), h.corner(n.E, n.H, n.F),
+	}
+}
+
+// corner returns the quarter of pixel e next to its neighbors v and h, which are
+// the pixels above or below and to the left or right of it
+func (h *hq2x) corner(e, v, hz uint8) uint8 {
+	if !h.similar(v, hz) || h.similar(e, v) {
+		return e
+	}
+
+	// a transparent edge can not be blended with a palette color, the opaque
+	// pixels keep their color so that the edge does not eat into the shape
+	if v == 0 {
+		return e
+	}
+
+	var sum [3]int
+
+	weight := 0
+
+	for _, contribution := range []struct {
+		idx    uint8
+		weight int
+	}{{e, 2}, {v, 1}, {hz, 1}} {
+		if contribution.idx == 0 {
+			continue
+		}
+
+		r, g, b := rgb8(paletteColor(h.palette, contribution.idx))
+		sum[0] += int(r) * contribution.weight
+		sum[1] += int(g) * contribution.weight
+		sum[2] += int(b) * contribution.weight
+		weight += contribution.weight
+	}
+
+	c := color.RGBA{R: uint8(sum[0] / weight), G: uint8(sum[1] / weight), B: uint8(sum[2] / weight), A: math.MaxUint8}
+
+	return h.snap(c)
+}
+
+func (h *hq2x) snap(c color.RGBA) uint8 {
+	if idx, found := h.closest[c]; found {
+		return idx
+	}
+
+	idx := uint8(0)
+	if len(h.candidates) > 0 {
+		idx = closestColor(h.palette, c, h.candidates)
+	}
+
+	h.closest[c] = idx
+
+	return idx
+}
+
+// similar compares colors in YUV space with the thresholds used by hqx
+func (h *hq2x) similar(a, b uint8) bool {
+	if a == b {
+		return true
+	}
+
+	if a == 0 || b == 0 {
+		return false
+	}
+
+	const (
+		thresholdY = 48
+		thresholdU = 7
+		thresholdV = 6
+	)
+
+	ya, ua, va := yuv(paletteColor(h.palette, a))
+	yb, ub, vb := yuv(paletteColor(h.palette, b))
+
+	return abs(ya-yb) <= thresholdY && abs(ua-ub) <= thresholdU && abs(va-vb) <= thresholdV
+}
+
+//nolint:gomnd // rgb to yuv conversion
+func yuv(c color.Color) (y, u, v int) {
+	r, g, b := rgb8(c)
+	rf, gf, bf := float64(r), float64(g), float64(b)
+
+	y = int(0.299*rf + 0.587*gf + 0.114*bf)
+	u = int(-0.169*rf - 0.331*gf + 0.5*bf + 128)
+	v = int(0.5*rf - 0.419*gf - 0.081*bf + 128)
+
+	return y, u, v
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestScale2xScale3x(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		n       neighborhood
+		scale2x []uint8
+		scale3x []uint8
+	}{
+		{
+			name:    "flat",
+			n:       neighborhood{A: 1, B: 1, C: 1, D: 1, E: 1, F: 1, G: 1, H: 1, I: 1},
+			scale2x: []uint8{1, 1, 1, 1},
+			scale3x: []uint8{1, 1, 1, 1, 1, 1, 1, 1, 1},
+		},
+		{
+			// a vertical line, B == H
+			name:    "line",
+			n:       neighborhood{A: 0, B: 2, C: 0, D: 1, E: 2, F: 3, G: 0, H: 2, I: 0},
+			scale2x: []uint8{2, 2, 2, 2},
+			scale3x: []uint8{2, 2, 2, 2, 2, 2, 2, 2, 2},
+		},
+		{
+			// the upper left corner of a diagonal edge, the edge goes on through C and G
+			name:    "upper left",
+			n:       neighborhood{A: 1, B: 1, C: 2, D: 1, E: 2, F: 2, G: 2, H: 2, I: 2},
+			scale2x: []uint8{1, 2, 2, 2},
+			scale3x: []uint8{1, 2, 2, 2, 2, 2, 2, 2, 2},
+		},
+		{
+			// like above, but the pixels beyond the edge differ from the corner
+			name:    "upper left, open",
+			n:       neighborhood{A: 0, B: 1, C: 0, D: 1, E: 2, F: 3, G: 0, H: 4, I: 0},
+			scale2x: []uint8{1, 2, 2, 2},
+			scale3x: []uint8{1, 1, 2, 1, 2, 2, 2, 2, 2},
+		},
+		{
+			// the lower right corner of a diagonal edge
+			name:    "lower right",
+			n:       neighborhood{A: 0, B: 1, C: 0, D: 2, E: 5, F: 3, G: 0, H: 3, I: 0},
+			scale2x: []uint8{5, 5, 5, 3},
+			scale3x: []uint8{5, 5, 5, 5, 5, 3, 5, 3, 3},
+		},
+		{
+			// a pixel between two colors, the corners next to them are cut
+			name:    "checkerboard",
+			n:       neighborhood{A: 2, B: 1, C: 2, D: 1, E: 2, F: 3, G: 2, H: 3, I: 2},
+			scale2x: []uint8{1, 2, 2, 3},
+			scale3x: []uint8{1, 2, 2, 2, 2, 2, 2, 2, 3},
+		},
+	} {
+		if got := scale2x(&test.n); !reflect.DeepEqual(got, test.scale2x) {
+			t.Fatalf("%s: expected scale2x %v, got %v", test.name, test.scale2x, got)
+		}
+
+		if got := scale3x(&test.n); !reflect.DeepEqual(got, test.scale3x) {
+			t.Fatalf("%s: expected scale3x %v, got %v", test.name, test.scale3x, got)
+		}
+	}
+}
+
+func TestScaleHQ2xUsesPalette(t *testing.T) {
+	p := color.Palette{
+		color.RGBA{},
+		color.RGBA{R: 0xff, A: 0xff},
+		color.RGBA{G: 0xff, A: 0xff},
+		color.RGBA{B: 0xff, A: 0xff},
+		color.RGBA{R: 0x80, G: 0x80, A: 0xff},
+		color.RGBA{R: 0xf0, G: 0x10, B: 0x10, A: 0xff},
+	}
+
+	// a diamond of two colors on a transparent background
+	src := image.NewPaletted(image.Rect(-5, -9, 4, 0), p)
+
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			switch d := abs(x) + abs(y+4); {
+			case d < 2:
+				src.SetColorIndex(x, y, 2)
+			case d < 4:
+				src.SetColorIndex(x, y, uint8(1+(x+y+16)%2*4))
+			}
+		}
+	}
+
+	f := newTestDCC(t, []*image.Paletted{src}).Direction(0).Frame(0)
+
+	dst, err := f.ScaledImage(ScaleHQ2x, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for y := dst.Rect.Min.Y; y < dst.Rect.Max.Y; y++ {
+		for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+			idx := dst.ColorIndexAt(x, y)
+
+			if int(idx) >= len(p) {
+				t.Fatalf("(%d,%d) has index %d, the palette has %d colors", x, y, idx, len(p))
+			}
+
+			if idx == 0 && src.ColorIndexAt(floorDiv(x, 2), floorDiv(y, 2)) != 0 {
+				t.Fatalf("(%d,%d) is transparent, but the source pixel is opaque", x, y)
+			}
+		}
+	}
+}
+
+func floorDiv(v, d int) int {
+	if v < 0 {
+		return -((-v + d - 1) / d)
+	}
+
+	return v / d
+}
+
+func TestScaleNearestKeepsAnchor(t *testing.T) {
+	// every pixel has the index of its position
+	src := image.NewPaletted(image.Rect(-4, -6, 2, 0), nil)
+	for idx := range src.Pix {
+		src.Pix[idx] = uint8(1 + idx)
+	}
+
+	f := newTestDCC(t, []*image.Paletted{src}).Direction(0).Frame(0)
+
+	for _, test := range []struct {
+		factor float64
+		bounds image.Rectangle
+		at     map[image.Point]image.Point // scaled pixel to source pixel
+	}{
+		{0.5, image.Rect(-2, -3, 1, 0), map[image.Point]image.Point{{-1, -1}: {-1, -1}, {-2, -3}: {-3, -5}, {0, -1}: {1, -1}}},
+		{1.5, image.Rect(-6, -9, 3, 0), map[image.Point]image.Point{{-1, -1}: {-1, -1}, {-6, -9}: {-4, -6}, {0, -1}: {0, -1}}},
+		{3, image.Rect(-12, -18, 6, 0), map[image.Point]image.Point{{-1, -1}: {-1, -1}, {-3, -3}: {-1, -1}, {-4, -1}: {-2, -1}, {5, -18}: {1, -6}}},
+	} {
+		dst, err := f.ScaledImage(ScaleNearest, test.factor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dst.Rect != test.bounds {
+			t.Fatalf("factor %v: expected bounds %v, got %v", test.factor, test.bounds, dst.Rect)
+		}
+
+		for at, from := range test.at {
+			if dst.ColorIndexAt(at.X, at.Y) != src.ColorIndexAt(from.X, from.Y) {
+				t.Fatalf("factor %v: expected %v to come from %v", test.factor, at, from)
+			}
+		}
+	}
+}
+
+func TestScaleFactor(t *testing.T) {
+	f := newTestDCC(t, []*image.Paletted{filledImage(image.Rect(-2, -2, 2, 0), 1)}).Direction(0).Frame(0)
+
+	for _, test := range []struct {
+		mode   ScaleMode
+		factor float64
+		ok     bool
+	}{
+		{ScaleScale2x, 2, true},
+		{ScaleScale2x, 3, false},
+		{ScaleEPX, 3, false},
+		{ScaleHQ2x, 3, false},
+		{ScaleScale3x, 3, true},
+		{ScaleScale3x, 2, false},
+		{ScaleNearest, 3, true},
+		{ScaleNearest, 0, false},
+		{ScaleNearest, -1, false},
+	} {
+		_, err := f.ScaledImage(test.mode, test.factor)
+
+		if test.ok && err != nil {
+			t.Fatalf("%v by %v: %v", test.mode, test.factor, err)
+		}
+
+		if !test.ok && !errors.Is(err, ErrScaleFactor) {
+			t.Fatalf("%v by %v: expected %v, got %v", test.mode, test.factor, ErrScaleFactor, err)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+const (
+	terminalAlphaThreshold = 0x80
+	sixelBandHeight        = 6
+	sixelMaxColors         = 256
+	kittyChunkSize         = 4096
+)
+
+// TerminalMode is the way an image is drawn on a terminal
+type TerminalMode int
+
+// Terminal modes
+const (
+	// TerminalHalfBlock draws two pixels per character with the upper and lower
+	// half block characters, in 24-bit ANSI colors. Most terminals support it.
+	TerminalHalfBlock TerminalMode = iota
+	// TerminalSixel draws the image with the DEC Sixel graphics protocol
+	TerminalSixel
+	// TerminalKitty draws the image with the kitty graphics protocol
+	TerminalKitty
+)
+
+var terminalModeNames = map[TerminalMode]string{
+	TerminalHalfBlock: "ansi",
+	TerminalSixel:     "sixel",
+	TerminalKitty:     "kitty",
+}
+
+func (m TerminalMode) String() string {
+	s, ok := terminalModeNames[m]
+	if !ok {
+		return "unknown"
+	}
+
+	return s
+}
+
+// TerminalModeFromString returns the terminal mode with the given name, as
+// yielded by TerminalMode.String
+func TerminalModeFromString(s string) (TerminalMode, error) {
+	for mode, name := range terminalModeNames {
+		if strings.EqualFold(name, s) {
+			return mode, nil
+		}
+	}
+
+	return TerminalHalfBlock, fmt.Errorf("unknown terminal mode %q", s)
+}
+
+// WriteTerminalImage writes the escape codes that draw the image at the cursor.
+// Pixels that are more than half transparent are left out, so the terminal
+// background shows through; only kitty blends partly transparent pixels. The
+// cursor ends up on the line below the image.
+func WriteTerminalImage(w io.Writer, img image.Image, mode TerminalMode) error {
+	bw := bufio.NewWriter(w)
+
+	if !img.Bounds().Empty() {
+		switch mode {
+		case TerminalSixel:
+			writeSixel(bw, img)
+		case TerminalKitty:
+			writeKitty(bw, img)
+		default:
+			writeHalfBlocks(bw, img)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// terminalColor returns the straight color of the pixel, and false if it is transparent
+func terminalColor(img image.Image, x, y int) (color.NRGBA, bool) {
+	if !(image.Point{X: x, Y: y}).In(img.Bounds()) {
+		return color.NRGBA{}, false
+	}
+
+	c, _ := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+
+	return c, c.A >= terminalAlphaThreshold
+}
+
+func writeHalfBlocks(w *bufio.Writer, img image.Image) {
+	const (
+		upperHalf = "▀"
+		lowerHalf = "▄"
+		reset     = "\x1b[0m"
+	)
+
+	r := img.Bounds()
+
+	// the colors are only written when they change
+	var fg, bg string
+
+	setColors := func(newFG, newBG string) {
+		if newFG != fg {
+			fg = newFG
+			w.WriteString(fg)
+		}
+
+		if newBG != bg {
+			bg = newBG
+			w.WriteString(bg)
+		}
+	}
+
+	foreground := func(c color.NRGBA) string { return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B) }
+	background := func(c color.NRGBA) string { return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B) }
+
+	for y := r.Min.Y; y < r.Max.Y; y += 2 {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			top, topOpaque := terminalColor(img, x, y)
+			bottom, bottomOpaque := terminalColor(img, x, y+1)
+
+			switch {
+			case topOpaque && bottomOpaque:
+				setColors(foreground(top), background(bottom))
+				w.WriteString(upperHalf)
+			case topOpaque:
+				setColors(foreground(top), "\x1b[49m")
+				w.WriteString(upperHalf)
+			case bottomOpaque:
+				setColors(foreground(bottom), "\x1b[49m")
+				w.WriteString(lowerHalf)
+			default:
+				setColors(fg, "\x1b[49m")
+				w.WriteByte(' ')
+			}
+		}
+
+		fg, bg = "", ""
+		w.WriteString(reset + "\n")
+	}
+}
+
+// writeSixel writes the image as a sixel image with a transparent background.
+// Sixel images have at most 256 colors, images with more colors are reduced to
+// a 6x6x6 color cube.
+func writeSixel(w *bufio.Writer, img image.Image) {
+	r := img.Bounds()
+	width, height := r.Dx(), r.Dy()
+
+	pixels, colors := sixelColors(img, false)
+	if len(colors) > sixelMaxColors {
+		pixels, colors = sixelColors(img, true)
+	}
+
+	// P2 = 1 leaves the pixels that are not drawn alone, they are transparent
+	fmt.Fprintf(w, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+
+	const percent = 100
+
+	for idx, c := range colors {
+		fmt.Fprintf(w, "#%d;2;%d;%d;%d", idx,
+			int(c.R)*percent/0xff, int(c.G)*percent/0xff, int(c.B)*percent/0xff)
+	}
+
+	for band := 0; band < height; band += sixelBandHeight {
+		for _, colorIdx := range sixelBandColors(pixels, width, height, band) {
+			fmt.Fprintf(w, "#%d", colorIdx)
+
+			rle := sixelRunLength{w: w}
+
+			for x := 0; x < width; x++ {
+				bits := 0
+
+				for row := 0; row < sixelBandHeight && band+row < height; row++ {
+					if pixels[(band+row)*width+x] == colorIdx {
+						bits |= 1 << row
+					}
+				}
+
+				rle.put(byte('?' + bits))
+			}
+
+			rle.flush()
+			w.WriteByte('$')
+		}
+
+		w.WriteByte('-')
+	}
+
+	w.WriteString("\x1b\\")
+}
+
+// sixelColors returns the color register of every pixel, -1 for transparent
+// pixels, and the colors of the registers
+func sixelColors(img image.Image, reduce bool) ([]int, []color.NRGBA) {
+	r := img.Bounds()
+	pixels := make([]int, 0, r.Dx()*r.Dy())
+	registers := make(map[color.NRGBA]int)
+	colors := make([]color.NRGBA, 0)
+
+	const cubeStep = 0x33 // 6 levels per channel
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c, opaque := terminalColor(img, x, y)
+			if !opaque {
+				pixels = append(pixels, -1)
+				continue
+			}
+
+			c.A = 0xff
+
+			if reduce {
+				level := func(v uint8) uint8 { return uint8((int(v) + cubeStep/2) / cubeStep * cubeStep) }
+				c.R, c.G, c.B = level(c.R), level(c.G), level(c.B)
+			}
+
+			idx, found := registers[c]
+			if !found {
+				idx = len(colors)
+				registers[c] = idx
+				colors = append(colors, c)
+			}
+
+			pixels = append(pixels, idx)
+		}
+	}
+
+	return pixels, colors
+}
+
+// sixelBandColors returns the color registers used in the band of six rows, in order
+func sixelBandColors(pixels []int, width, height, band int) []int {
+	used := make(map[int]bool)
+	result := make([]int, 0)
+
+	for y := band; y < band+sixelBandHeight && y < height; y++ {
+		for _, idx := range pixels[y*width : (y+1)*width] {
+			if idx < 0 || used[idx] {
+				continue
+			}
+
+			used[idx] = true
+			result = append(result, idx)
+		}
+	}
+
+	return result
+}
+
+// sixelRunLength writes sixel characters, repeated characters are run-length encoded
+type sixelRunLength struct {
+	w     *bufio.Writer
+	last  byte
+	count int
+}
+
+func (s *sixelRunLength) put(c byte) {
+	if c == s.last {
+		s.count++
+		return
+	}
+
+	s.flush()
+	s.last, s.count = c, 1
+}
+
+func (s *sixelRunLength) flush() {
+	// a repeat introducer is only shorter for more than three characters
+	const minRepeat = 4
+
+	switch {
+	case s.count >= minRepeat:
+		fmt.Fprintf(s.w, "!%d%c", s.count, s.last)
+	case s.count > 0:
+		s.w.WriteString(strings.Repeat(string(s.last), s.count))
+	}
+
+	s.count = 0
+}
+
+// writeKitty sends the image as 32-bit RGBA pixels with the kitty graphics
+// protocol. The terminal is asked not to answer, so nothing is sent back on stdin.
+func writeKitty(w *bufio.Writer, img image.Image) {
+	const bytesPerPixel = 4
+
+	r := img.Bounds()
+
+	data := make([]byte, 0, r.Dx()*r.Dy()*bytesPerPixel)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c, _ := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			data = append(data, c.R, c.G, c.B, c.A)
+		}
+	}
+
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	// the payload is sent in chunks, m=1 tells the terminal that more follow
+	for start := 0; start < len(payload); start += kittyChunkSize {
+		end := start + kittyChunkSize
+		more := 1
+
+		if end >= len(payload) {
+			end, more = len(payload), 0
+		}
+
+		if start == 0 {
+			fmt.Fprintf(w, "\x1b_Gf=32,s=%d,v=%d,a=T,q=2,m=%d;", r.Dx(), r.Dy(), more)
+		} else {
+			fmt.Fprintf(w, "\x1b_Gm=%d;", more)
+		}
+
+		w.WriteString(payload[start:end])
+		w.WriteString("\x1b\\")
+	}
+
+	w.WriteByte('\n')
+}
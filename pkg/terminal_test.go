@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	testRed   = color.NRGBA{R: 0xff, A: 0xff}
+	testGreen = color.NRGBA{G: 0xff, A: 0xff}
+	testBlue  = color.NRGBA{B: 0xff, A: 0xff}
+)
+
+// terminalTestImage returns a 2x3 image:
+//
+//	red   -
+//	green red
+//	-     blue
+func terminalTestImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(-1, -3, 1, 0))
+	img.SetNRGBA(-1, -3, testRed)
+	img.SetNRGBA(-1, -2, testGreen)
+	img.SetNRGBA(0, -2, testRed)
+	img.SetNRGBA(0, -1, testBlue)
+
+	// more than half transparent is left out
+	img.SetNRGBA(0, -3, color.NRGBA{R: 0xff, A: 0x7f})
+
+	return img
+}
+
+func terminalImage(t *testing.T, img image.Image, mode TerminalMode) string {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+
+	if err := WriteTerminalImage(buf, img, mode); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestTerminalHalfBlocks(t *testing.T) {
+	const want = "\x1b[38;2;255;0;0m\x1b[48;2;0;255;0m▀\x1b[49m▄\x1b[0m\n" +
+		"\x1b[49m \x1b[38;2;0;0;255m▀\x1b[0m\n"
+
+	if got := terminalImage(t, terminalTestImage(), TerminalHalfBlock); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTerminalSixel(t *testing.T) {
+	// one band, every register is drawn on its own row of sixels
+	const want = "\x1bP0;1;0q\"1;1;2;3" +
+		"#0;2;100;0;0#1;2;0;100;0#2;2;0;0;100" +
+		"#0@A$#1A?$#2?C$-" +
+		"\x1b\\"
+
+	if got := terminalImage(t, terminalTestImage(), TerminalSixel); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTerminalSixelBands(t *testing.T) {
+	// seven rows take two bands, the long runs are run-length encoded
+	img := image.NewNRGBA(image.Rect(0, 0, 5, 7))
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 5; x++ {
+			img.SetNRGBA(x, y, testRed)
+		}
+	}
+
+	img.SetNRGBA(4, 6, testBlue)
+
+	const want = "\x1bP0;1;0q\"1;1;5;7" +
+		"#0;2;100;0;0#1;2;0;0;100" +
+		"#0!5~$-" +
+		"#0!4@?$#1!4?@$-" +
+		"\x1b\\"
+
+	if got := terminalImage(t, img, TerminalSixel); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSixelRunLength(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	rle := sixelRunLength{w: w}
+
+	for _, c := range "AAAAABBBCCCCD" {
+		rle.put(byte(c))
+	}
+
+	rle.flush()
+	_ = w.Flush()
+
+	if buf.String() != "!5ABBB!4CD" {
+		t.Fatalf("expected !5ABBB!4CD, got %q", buf.String())
+	}
+}
+
+func TestTerminalSixelReducesColors(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, sixelMaxColors+1, 1))
+	for x := 0; x <= sixelMaxColors; x++ {
+		img.SetNRGBA(x, 0, color.NRGBA{R: uint8(x), G: uint8(x / 2), B: 0x20, A: 0xff})
+	}
+
+	registers := regexp.MustCompile(`#(\d+);2;(\d+);(\d+);(\d+)`).FindAllStringSubmatch(terminalImage(t, img, TerminalSixel), -1)
+
+	const cubeColors = 6 * 6 * 6
+
+	if len(registers) == 0 || len(registers) > cubeColors {
+		t.Fatalf("expected at most %d registers, got %d", cubeColors, len(registers))
+	}
+
+	// the 6x6x6 cube has the levels 0, 20, 40, 60, 80 and 100 percent
+	for _, register := range registers {
+		for _, level := range register[2:] {
+			if !strings.Contains(" 0 20 40 60 80 100 ", " "+level+" ") {
+				t.Fatalf("register %s is not in the color cube", register[0])
+			}
+		}
+	}
+}
+
+func TestTerminalKitty(t *testing.T) {
+	img := terminalTestImage()
+
+	payload := base64.StdEncoding.EncodeToString([]byte{
+		0xff, 0, 0, 0xff, 0xff, 0, 0, 0x7f,
+		0, 0xff, 0, 0xff, 0xff, 0, 0, 0xff,
+		0, 0, 0, 0, 0, 0, 0xff, 0xff,
+	})
+	want := "\x1b_Gf=32,s=2,v=3,a=T,q=2,m=0;" + payload + "\x1b\\\n"
+
+	if got := terminalImage(t, img, TerminalKitty); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTerminalKittyChunks(t *testing.T) {
+	// 40x40 pixels are 6400 bytes, or 8536 base64 characters
+	const size = 40
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for idx := range img.Pix {
+		img.Pix[idx] = uint8(idx)
+	}
+
+	chunks := regexp.MustCompile("\x1b_G([^;]*);([^\x1b]*)\x1b\\\\").FindAllStringSubmatch(terminalImage(t, img, TerminalKitty), -1)
+
+	controls := []string{"f=32,s=40,v=40,a=T,q=2,m=1", "m=1", "m=0"}
+	if len(chunks) != len(controls) {
+		t.Fatalf("expected %d chunks, got %d", len(controls), len(chunks))
+	}
+
+	payload := ""
+
+	for idx, chunk := range chunks {
+		if chunk[1] != controls[idx] {
+			t.Fatalf("chunk %d, expected %q, got %q", idx, controls[idx], chunk[1])
+		}
+
+		if idx < len(chunks)-1 && len(chunk[2]) != kittyChunkSize {
+			t.Fatalf("chunk %d, expected %d bytes, got %d", idx, kittyChunkSize, len(chunk[2]))
+		}
+
+		payload += chunk[2]
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, img.Pix) {
+		t.Fatal("the pixels differ")
+	}
+}
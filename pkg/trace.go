@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/OpenDiablo2/bitstream"
+)
+
+const bitsPerByte = 8
+
+// Names of the streams that trace events are read from
+const (
+	StreamHeader        = "Header"
+	StreamEqualCells    = "EqualCells"
+	StreamPixelMask     = "PixelMask"
+	StreamEncodingType  = "EncodingType"
+	StreamRawPixelCodes = "RawPixelCodes"
+	StreamPixelCodes    = "PixelCodesAndDisplacement"
+)
+
+// TraceEventKind is the kind of value that a trace event was read for
+type TraceEventKind int
+
+// Trace event kinds
+const (
+	TraceHeaderField TraceEventKind = iota
+	TraceEqualCell
+	TracePixelMask
+	TraceEncodingType
+	TracePixelStack
+	TracePixelCode
+)
+
+var traceEventKindNames = map[TraceEventKind]string{
+	TraceHeaderField:  "HeaderField",
+	TraceEqualCell:    "EqualCell",
+	TracePixelMask:    "PixelMask",
+	TraceEncodingType: "EncodingType",
+	TracePixelStack:   "PixelStack",
+	TracePixelCode:    "PixelCode",
+}
+
+func (k TraceEventKind) String() string {
+	s, ok := traceEventKindNames[k]
+	if !ok {
+		return "Unknown"
+	}
+
+	return s
+}
+
+// MarshalText encodes the kind as its name
+func (k TraceEventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// TraceEvent describes a single value read by the decoder
+type TraceEvent struct {
+	Kind   TraceEventKind `json:"kind"`
+	Field  string         `json:"field"`
+	Stream string         `json:"stream"`
+	// BitPosition is the absolute position in the file, in bits, of the first bit of the value
+	BitPosition int    `json:"bitPosition"`
+	NumBits     int    `json:"numBits"`
+	Value       uint64 `json:"value"`
+	// Direction, Frame and Cell are -1 when the value does not belong to one
+	Direction int `json:"direction"`
+	Frame     int `json:"frame"`
+	Cell      int `json:"cell"`
+}
+
+// Tracer receives an event for every value the decoder reads. The event is
+// only valid for the duration of the call.
+type Tracer interface {
+	Trace(event *TraceEvent)
+}
+
+// TraceFormat is the output format of a LogTracer
+type TraceFormat int
+
+// Trace formats
+const (
+	TraceFormatText TraceFormat = iota
+	TraceFormatJSON
+)
+
+// LogTracer is a Tracer that writes every event as a line of text or JSON
+type LogTracer struct {
+	w      io.Writer
+	format TraceFormat
+	err    error
+}
+
+// NewLogTracer creates a tracer that writes to w in the given format
+func NewLogTracer(w io.Writer, format TraceFormat) *LogTracer {
+	return &LogTracer{w: w, format: format}
+}
+
+// Trace writes the event to the log
+func (t *LogTracer) Trace(e *TraceEvent) {
+	if t.err != nil {
+		return
+	}
+
+	if t.format == TraceFormatJSON {
+		var data []byte
+
+		if data, t.err = json.Marshal(e); t.err == nil {
+			_, t.err = fmt.Fprintf(t.w, "%s\n", data)
+		}
+
+		return
+	}
+
+	const fmtLine = "%10d %-26s %-12s dir=%-3d frame=%-3d cell=%-4d %-24s %2d bits = %d\n"
+
+	_, t.err = fmt.Fprintf(t.w, fmtLine,
+		e.BitPosition, e.Stream, e.Kind, e.Direction, e.Frame, e.Cell, e.Field, e.NumBits, e.Value)
+}
+
+// Err returns the first error that occurred while writing the log
+func (t *LogTracer) Err() error {
+	return t.err
+}
+
+// traceState holds the tracer and where the decoder currently is
+type traceState struct {
+	tracer    Tracer
+	direction int
+	frame     int
+	cell      int
+	event     TraceEvent
+}
+
+func (t *traceState) reset() {
+	t.direction, t.frame, t.cell = none, none, none
+}
+
+// SetTracer sets the tracer that is given every value read while decoding,
+// use nil to stop tracing.
+func (d *DCC) SetTracer(t Tracer) *DCC {
+	d.trace.tracer = t
+
+	return d
+}
+
+// FromBytesTraced decodes the bytes like FromBytes, reporting every value
+// read from the file to the tracer.
+func FromBytesTraced(data []byte, t Tracer) (*DCC, error) {
+	return New().SetTracer(t).FromBytes(data)
+}
+
+// next reads the next n bits from the stream, reporting them to the tracer if there is one
+func (d *DCC) next(stream *bitstream.Reader, kind TraceEventKind, streamName, field string, n int) bitstream.Response {
+	if d.trace.tracer == nil {
+		return stream.Next(n).Bits()
+	}
+
+	position := stream.Position()*bitsPerByte + stream.BitPosition()
+	response := stream.Next(n).Bits()
+
+	d.trace.event = TraceEvent{
+		Kind:        kind,
+		Field:       field,
+		Stream:      streamName,
+		BitPosition: position,
+		NumBits:     n,
+		Value:       uint64(response.Bits.AsUInt()),
+		Direction:   d.trace.direction,
+		Frame:       d.trace.frame,
+		Cell:        d.trace.cell,
+	}
+
+	d.trace.tracer.Trace(&d.trace.event)
+
+	return response
+}
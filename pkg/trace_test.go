@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"image"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// traceLine is what the tests read back from a line of the trace log
+type traceLine struct {
+	Kind        string `json:"kind"`
+	Field       string `json:"field"`
+	Stream      string `json:"stream"`
+	BitPosition int    `json:"bitPosition"`
+	NumBits     int    `json:"numBits"`
+	Value       uint64 `json:"value"`
+}
+
+// parseTextTrace reads the lines written by a text LogTracer
+func parseTextTrace(t *testing.T, log []byte) []traceLine {
+	t.Helper()
+
+	var lines []traceLine
+
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+	for scanner.Scan() {
+		// position, stream, kind, dir, frame, cell, field, bits, "bits", "=", value
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 11 {
+			t.Fatalf("unexpected trace line %q", scanner.Text())
+		}
+
+		position, err := strconv.Atoi(fields[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		numBits, err := strconv.Atoi(fields[7])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := strconv.ParseUint(fields[10], 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, traceLine{fields[2], fields[6], fields[1], position, numBits, value})
+	}
+
+	return lines
+}
+
+// parseJSONTrace reads the lines written by a JSON LogTracer
+func parseJSONTrace(t *testing.T, log []byte) []traceLine {
+	t.Helper()
+
+	var lines []traceLine
+
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+	for scanner.Scan() {
+		var line traceLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("unexpected trace line %q: %v", scanner.Text(), err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestTraceHeader(t *testing.T) {
+	d := newTestDCC(t,
+		[]*image.Paletted{filledImage(image.Rect(-2, -3, 2, 0), 7)},
+		[]*image.Paletted{filledImage(image.Rect(0, -2, 3, 0), 9)},
+	)
+
+	data, err := d.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []traceLine{
+		{"HeaderField", "Signature", StreamHeader, 0, 8, uint64(fileSignature)},
+		{"HeaderField", "Version", StreamHeader, 8, 8, uint64(decoded.Version)},
+		{"HeaderField", "Directions", StreamHeader, 16, 8, 2},
+		{"HeaderField", "FramesPerDirection", StreamHeader, 24, 32, 1},
+		{"HeaderField", "SanityCheck", StreamHeader, 56, 32, uint64(sanityCheck1)},
+		{"HeaderField", "TotalSizeCoded", StreamHeader, 88, 32, uint64(decoded.TotalSizeCoded)},
+		{"HeaderField", "DirectionOffset", StreamHeader, 120, 32, uint64(decoded.DirectionOffsets[0])},
+	}
+
+	// the first direction is decoded before the offset of the next one is read
+	direction := int(decoded.DirectionOffsets[0]) * bitsPerByte
+
+	for _, test := range []struct {
+		name   string
+		format TraceFormat
+		parse  func(*testing.T, []byte) []traceLine
+	}{
+		{"text", TraceFormatText, parseTextTrace},
+		{"json", TraceFormatJSON, parseJSONTrace},
+	} {
+		var log bytes.Buffer
+
+		tracer := NewLogTracer(&log, test.format)
+
+		if _, err := FromBytesTraced(data, tracer); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if err := tracer.Err(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		got := test.parse(t, log.Bytes())
+		if len(got) <= len(want) {
+			t.Fatalf("%s: expected more than %d events, got %d", test.name, len(want), len(got))
+		}
+
+		for idx, w := range want {
+			if got[idx] != w {
+				t.Fatalf("%s: event %d, expected %+v, got %+v", test.name, idx, w, got[idx])
+			}
+		}
+
+		if next := got[len(want)]; next.Field != "OutSizeCoded" || next.BitPosition != direction {
+			t.Fatalf("%s: expected OutSizeCoded at bit %d, got %s at bit %d", test.name, direction, next.Field, next.BitPosition)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import "image"
+
+// Trim shrinks the frame to its opaque bounds. The offsets are adjusted so the
+// pixels stay in the same place relative to the origin, and the direction box
+// is updated to match. A fully transparent frame becomes a single transparent
+// pixel at the origin.
+func (f *Frame) Trim() {
+	d := f.direction
+	if d == nil {
+		f.trim()
+		return
+	}
+
+	d.edit(f.trim)
+}
+
+func (f *Frame) trim() {
+	bounds := f.OpaqueBounds()
+	if bounds.Empty() {
+		f.setPixels(image.NewPaletted(image.Rect(0, 0, 1, 1), nil))
+		return
+	}
+
+	if bounds.Eq(f.Box) {
+		return
+	}
+
+	f.setPixels(f.Paletted().SubImage(bounds).(*image.Paletted))
+}
+
+// Trim shrinks every frame of the direction to its opaque bounds, see Frame.Trim
+func (d *Direction) Trim() {
+	d.edit(func() {
+		for _, f := range d.frames {
+			f.trim()
+		}
+	})
+}
+
+// Trim shrinks every frame of every direction to its opaque bounds, see Frame.Trim
+func (d *DCC) Trim() {
+	for _, dir := range d.directions {
+		dir.Trim()
+	}
+}
@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"image"
+	"testing"
+)
+
+// checkOpaquePixels checks that the frame has the opaque pixels of the image
+// at the same coordinates, and no others
+func checkOpaquePixels(t *testing.T, name string, f *Frame, img *image.Paletted) {
+	t.Helper()
+
+	if f.OpaqueBounds() != opaqueBounds(img) {
+		t.Fatalf("%s: expected the opaque bounds %v, got %v", name, opaqueBounds(img), f.OpaqueBounds())
+	}
+
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if want := img.ColorIndexAt(x, y); f.ColorIndexAt(x, y) != want {
+				t.Fatalf("%s: (%d,%d), expected color %d, got %d", name, x, y, want, f.ColorIndexAt(x, y))
+			}
+		}
+	}
+}
+
+func opaqueBounds(img *image.Paletted) image.Rectangle {
+	return NewFrame(img).OpaqueBounds()
+}
+
+func TestTrim(t *testing.T) {
+	images := [][]*image.Paletted{
+		{
+			paddedImage(image.Rect(-20, -40, 20, 0), image.Rect(-5, -30, 7, -2)),
+			paddedImage(image.Rect(-8, -50, 30, 9), image.Rect(3, -44, 6, 4)),
+			image.NewPaletted(image.Rect(-10, -10, 10, 10), nil),
+		},
+	}
+
+	d := newTestDCC(t, images...)
+	d.Trim()
+
+	dir := d.Direction(0)
+	box := image.Rectangle{}
+
+	for idx, img := range images[0][:2] {
+		f := dir.Frame(idx)
+
+		checkOpaquePixels(t, "trimmed", f, img)
+
+		if f.Box != opaqueBounds(img) {
+			t.Fatalf("frame %d, expected the box %v, got %v", idx, opaqueBounds(img), f.Box)
+		}
+
+		box = box.Union(f.Box)
+	}
+
+	// the transparent frame is a single pixel at the origin
+	if blank := dir.Frame(2); blank.Box != image.Rect(0, 0, 1, 1) || blank.ColorIndexAt(0, 0) != 0 {
+		t.Fatalf("expected a transparent pixel at the origin, got %v", blank.Box)
+	}
+
+	if *dir.Box != box.Union(image.Rect(0, 0, 1, 1)) {
+		t.Fatalf("expected the direction box %v, got %v", box.Union(image.Rect(0, 0, 1, 1)), *dir.Box)
+	}
+}
+
+func TestTrimFrame(t *testing.T) {
+	img := paddedImage(image.Rect(-30, -30, 30, 30), image.Rect(-2, -6, 2, 0))
+	d := newTestDCC(t, []*image.Paletted{img, paddedImage(image.Rect(-4, -4, 4, 0), image.Rect(-4, -4, 4, 0))})
+
+	d.Direction(0).Frame(0).Trim()
+
+	checkOpaquePixels(t, "trimmed", d.Direction(0).Frame(0), img)
+
+	if box := *d.Direction(0).Box; box != image.Rect(-4, -6, 4, 0) {
+		t.Fatalf("expected the direction box to shrink to (-4,-6)-(4,0), got %v", box)
+	}
+}
+
+func TestEncodeTrimmed(t *testing.T) {
+	// the opaque pixels start on 16 pixel regions, so they encode without loss
+	opaque := []image.Rectangle{image.Rect(-16, -48, 13, 0), image.Rect(0, -32, 21, -5)}
+	images := make([]*image.Paletted, len(opaque))
+
+	for idx, r := range opaque {
+		images[idx] = image.NewPaletted(image.Rect(-64, -96, 64, 32), nil)
+		region := regionImage(r, idx)
+
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				images[idx].SetColorIndex(x, y, region.ColorIndexAt(x, y))
+			}
+		}
+	}
+
+	full, err := newTestDCC(t, images).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trimmed, err := newTestDCC(t, images).EncodeWith(EncodeOptions{Trim: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trimmed) >= len(full) {
+		t.Fatalf("expected trimming to make the file smaller, %d >= %d bytes", len(trimmed), len(full))
+	}
+
+	decoded, err := FromBytes(trimmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, img := range images {
+		f := decoded.Direction(0).Frame(idx)
+
+		checkOpaquePixels(t, "decoded", f, img)
+
+		if f.Box != opaque[idx] {
+			t.Fatalf("frame %d, expected the box %v, got %v", idx, opaque[idx], f.Box)
+		}
+	}
+}